@@ -1,14 +1,26 @@
 module github.com/blrobin2/goreddit
 
-go 1.16
+go 1.20
 
 require (
 	github.com/alexedwards/scs/postgresstore v0.0.0-20210606090158-85ec2fab6bdf
+	github.com/alexedwards/scs/sqlite3store v0.0.0-20210606090158-85ec2fab6bdf
 	github.com/alexedwards/scs/v2 v2.4.0
 	github.com/go-chi/chi/v5 v5.0.3
 	github.com/google/uuid v1.2.0
 	github.com/gorilla/csrf v1.7.0
 	github.com/jmoiron/sqlx v1.3.4
 	github.com/lib/pq v1.10.2
-	golang.org/x/crypto v0.0.0-20210513164829-c07d793c2f9a
+	github.com/mattn/go-sqlite3 v1.14.7
+	github.com/microcosm-cc/bluemonday v1.0.27
+	github.com/yuin/goldmark v1.4.13
+	golang.org/x/crypto v0.24.0
+)
+
+require (
+	github.com/aymerick/douceur v0.2.0 // indirect
+	github.com/gorilla/css v1.0.1 // indirect
+	github.com/gorilla/securecookie v1.1.1 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	golang.org/x/net v0.26.0 // indirect
 )
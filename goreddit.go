@@ -1,72 +1,504 @@
 package goreddit
 
-import "github.com/google/uuid"
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Thread struct {
 	ID          uuid.UUID `db:"id"`
+	UserID      uuid.UUID `db:"user_id"`
 	Title       string    `db:"title"`
 	Description string    `db:"description"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
 }
 
 type Post struct {
-	ID            uuid.UUID `db:"id"`
-	ThreadID      uuid.UUID `db:"thread_id"`
-	Title         string    `db:"title"`
-	Content       string    `db:"content"`
-	Votes         int       `db:"votes"`
-	CommentsCount int       `db:"comments_count"`
-	ThreadTitle   string    `db:"thread_title"`
+	ID       uuid.UUID `db:"id"`
+	ThreadID uuid.UUID `db:"thread_id"`
+	UserID   uuid.UUID `db:"user_id"`
+	Title    string    `db:"title"`
+	Content  string    `db:"content"`
+	// ContentHTML is Content rendered from Markdown, if it has been.
+	ContentHTML string `db:"content_html"`
+	// Kind says whether the post shares text, a link or an image. Link
+	// posts have a URL, and Domain is the host it points at. Image posts
+	// have the blob keys of the uploaded Image and of its Thumbnail.
+	Kind      PostKind   `db:"kind"`
+	URL       string     `db:"url"`
+	Domain    string     `db:"domain"`
+	Image     string     `db:"image"`
+	Thumbnail string     `db:"thumbnail"`
+	Votes     int        `db:"votes"`
+	Upvotes   int        `db:"upvotes"`
+	Downvotes int        `db:"downvotes"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at"`
+	EditedAt  *time.Time `db:"edited_at"`
+	// Locked posts take no new comments. Pinned posts are listed first in
+	// their thread.
+	Locked        bool   `db:"locked"`
+	Pinned        bool   `db:"pinned"`
+	CommentsCount int    `db:"comments_count"`
+	ThreadTitle   string `db:"thread_title"`
+	Username      string `db:"username"`
+}
+
+type PostKind string
+
+// Posts without a kind are text posts.
+const (
+	PostText  PostKind = "text"
+	PostLink  PostKind = "link"
+	PostImage PostKind = "image"
+)
+
+// IsLink reports whether the post shares a link.
+func (p Post) IsLink() bool {
+	return p.Kind == PostLink
+}
+
+// IsImage reports whether the post shares an uploaded image.
+func (p Post) IsImage() bool {
+	return p.Kind == PostImage
 }
 
 type Comment struct {
-	ID      uuid.UUID `db:"id"`
-	PostID  uuid.UUID `db:"post_id"`
-	Content string    `db:"content"`
-	Votes   int       `db:"votes"`
+	ID       uuid.UUID `db:"id"`
+	PostID   uuid.UUID `db:"post_id"`
+	UserID   uuid.UUID `db:"user_id"`
+	ParentID uuid.UUID `db:"parent_id"`
+	Content  string    `db:"content"`
+	// ContentHTML is Content rendered from Markdown, if it has been.
+	ContentHTML string     `db:"content_html"`
+	Votes       int        `db:"votes"`
+	Upvotes     int        `db:"upvotes"`
+	Downvotes   int        `db:"downvotes"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+	EditedAt    *time.Time `db:"edited_at"`
+	// Deleted is set on comments that were deleted while they had replies.
+	// They are kept, without content or author, to hold the discussion
+	// together.
+	Deleted   bool   `db:"deleted"`
+	Username  string `db:"username"`
+	Depth     int    `db:"depth"`
+	PostTitle string `db:"post_title"`
 }
 
 type User struct {
-	ID       uuid.UUID `db:"id"`
-	Username string    `db:"username"`
-	Password string    `db:"password"`
+	ID        uuid.UUID `db:"id"`
+	Username  string    `db:"username"`
+	Password  string    `db:"password"`
+	Role      string    `db:"role"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+	// PostKarma and CommentKarma are the net votes other users have cast on
+	// the user's posts and comments. Votes keep them up to date, and they
+	// are kept when the content is deleted.
+	PostKarma    int `db:"post_karma"`
+	CommentKarma int `db:"comment_karma"`
+}
+
+// Karma is the user's total karma.
+func (u User) Karma() int {
+	return u.PostKarma + u.CommentKarma
+}
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// IsAdmin reports whether the user may manage any content on the site.
+func (u User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// ThreadModerator lets a user moderate a thread. Whoever creates a thread
+// becomes its first moderator.
+type ThreadModerator struct {
+	ThreadID  uuid.UUID `db:"thread_id"`
+	UserID    uuid.UUID `db:"user_id"`
+	CreatedAt time.Time `db:"created_at"`
+	Username  string    `db:"username"`
+}
+
+// ThreadBan keeps a user from posting, commenting and voting in a thread
+// until ExpiresAt, or for good if it is nil.
+type ThreadBan struct {
+	ThreadID  uuid.UUID  `db:"thread_id"`
+	UserID    uuid.UUID  `db:"user_id"`
+	BannedBy  uuid.UUID  `db:"banned_by"`
+	Reason    string     `db:"reason"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt *time.Time `db:"expires_at"`
+	Username  string     `db:"username"`
+}
+
+// Suspension keeps a user from logging in and from taking part anywhere on
+// the site until ExpiresAt, or for good if it is nil.
+type Suspension struct {
+	UserID      uuid.UUID  `db:"user_id"`
+	SuspendedBy uuid.UUID  `db:"suspended_by"`
+	Reason      string     `db:"reason"`
+	CreatedAt   time.Time  `db:"created_at"`
+	ExpiresAt   *time.Time `db:"expires_at"`
+}
+
+// Report flags a post or comment for the thread's moderators. CommentID is
+// the zero UUID for reports on posts.
+type Report struct {
+	ID        uuid.UUID `db:"id"`
+	PostID    uuid.UUID `db:"post_id"`
+	CommentID uuid.UUID `db:"comment_id"`
+	UserID    uuid.UUID `db:"user_id"`
+	Reason    string    `db:"reason"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// ReportedItem is a post or comment waiting in a moderation queue, with the
+// reports against it, oldest first. Content, UserID and Username are the
+// comment's for comments and the post's for posts.
+type ReportedItem struct {
+	PostID    uuid.UUID `db:"post_id"`
+	CommentID uuid.UUID `db:"comment_id"`
+	PostTitle string    `db:"post_title"`
+	Content   string    `db:"content"`
+	UserID    uuid.UUID `db:"user_id"`
+	Username  string    `db:"username"`
+	Reports   []Report  `db:"-"`
+}
+
+// IsComment reports whether the item is a comment rather than a post.
+func (i ReportedItem) IsComment() bool {
+	return i.CommentID != uuid.Nil
+}
+
+// ModLogEntry records something a moderator did in a thread. Target
+// describes the post or comment acted on, such as its title, since the
+// content itself may be gone. TargetUserID is the user whose content or
+// status was affected.
+type ModLogEntry struct {
+	ID           uuid.UUID `db:"id"`
+	ThreadID     uuid.UUID `db:"thread_id"`
+	ActorID      uuid.UUID `db:"actor_id"`
+	Action       ModAction `db:"action"`
+	TargetUserID uuid.UUID `db:"target_user_id"`
+	PostID       uuid.UUID `db:"post_id"`
+	CommentID    uuid.UUID `db:"comment_id"`
+	Target       string    `db:"target"`
+	Reason       string    `db:"reason"`
+	CreatedAt    time.Time `db:"created_at"`
+	ActorName    string    `db:"actor_name"`
+	TargetName   string    `db:"target_name"`
+}
+
+type ModAction string
+
+const (
+	ModRemovePost      ModAction = "remove_post"
+	ModRemoveComment   ModAction = "remove_comment"
+	ModApprovePost     ModAction = "approve_post"
+	ModApproveComment  ModAction = "approve_comment"
+	ModLockPost        ModAction = "lock_post"
+	ModUnlockPost      ModAction = "unlock_post"
+	ModPinPost         ModAction = "pin_post"
+	ModUnpinPost       ModAction = "unpin_post"
+	ModAddModerator    ModAction = "add_moderator"
+	ModRemoveModerator ModAction = "remove_moderator"
+	ModEditThread      ModAction = "edit_thread"
+	ModBanUser         ModAction = "ban_user"
+	ModUnbanUser       ModAction = "unban_user"
+)
+
+// ModActions lists every moderator action, in the order they are offered
+// as filters.
+var ModActions = []ModAction{
+	ModRemovePost,
+	ModRemoveComment,
+	ModApprovePost,
+	ModApproveComment,
+	ModLockPost,
+	ModUnlockPost,
+	ModPinPost,
+	ModUnpinPost,
+	ModAddModerator,
+	ModRemoveModerator,
+	ModEditThread,
+	ModBanUser,
+	ModUnbanUser,
+}
+
+// ModLogFilter narrows a moderation log to one action and one moderator.
+// Zero values match everything.
+type ModLogFilter struct {
+	Action  ModAction
+	ActorID uuid.UUID
 }
 
+// APIToken lets scripts act as a user. Only a hash of the token is stored;
+// the token itself is shown once when it is created.
+type APIToken struct {
+	ID         uuid.UUID  `db:"id"`
+	UserID     uuid.UUID  `db:"user_id"`
+	Name       string     `db:"name"`
+	Hash       []byte     `db:"token_hash"`
+	CreatedAt  time.Time  `db:"created_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+}
+
+type PostSort string
+
+const (
+	SortHot           PostSort = "hot"
+	SortNew           PostSort = "new"
+	SortTop           PostSort = "top"
+	SortControversial PostSort = "controversial"
+)
+
+// PostListing selects the order of a post listing. Window limits top and
+// controversial listings to posts created within that long of now; zero
+// means all time.
+type PostListing struct {
+	Sort   PostSort
+	Window time.Duration
+}
+
+// Page selects part of a listing using the ID of the item it continues from:
+// After returns the items following that one in listing order, Before the
+// items preceding it. At most one of them is set. A Limit of zero or less
+// returns the rest of the listing.
+type Page struct {
+	After  uuid.UUID
+	Before uuid.UUID
+	Limit  int
+}
+
+// SearchQuery selects posts and comments that contain every phrase, each of
+// which is one or more words. Thread and Author optionally restrict results
+// to a thread title and an author's username, ignoring case.
+type SearchQuery struct {
+	Phrases []string
+	Thread  string
+	Author  string
+}
+
+// Search snippets surround the words that matched a query with MatchStart
+// and MatchEnd.
+const (
+	MatchStart = "\x02"
+	MatchEnd   = "\x03"
+)
+
+// SearchResult is a post or comment that matched a search. CommentID is the
+// zero UUID for posts.
+type SearchResult struct {
+	PostID      uuid.UUID `db:"post_id"`
+	CommentID   uuid.UUID `db:"comment_id"`
+	ThreadID    uuid.UUID `db:"thread_id"`
+	ThreadTitle string    `db:"thread_title"`
+	PostTitle   string    `db:"post_title"`
+	Username    string    `db:"username"`
+	CreatedAt   time.Time `db:"created_at"`
+	Snippet     string    `db:"snippet"`
+	Rank        float64   `db:"rank"`
+}
+
+// IsComment reports whether the result is a comment rather than a post.
+func (r SearchResult) IsComment() bool {
+	return r.CommentID != uuid.Nil
+}
+
+// Stores return these errors, possibly wrapped, when a row does not exist
+// (including rows a write refers to) and when a write would violate a
+// uniqueness constraint, such as a taken username.
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+)
+
 type ThreadStore interface {
-	Thread(id uuid.UUID) (Thread, error)
-	Threads() ([]Thread, error)
-	CreateThread(t *Thread) error
-	UpdateThread(t *Thread) error
-	DeleteThread(id uuid.UUID) error
+	Thread(ctx context.Context, id uuid.UUID) (Thread, error)
+	Threads(ctx context.Context, p Page) ([]Thread, error)
+	CreateThread(ctx context.Context, t *Thread) error
+	UpdateThread(ctx context.Context, t *Thread) error
+	DeleteThread(ctx context.Context, id uuid.UUID) error
 }
 
 type PostStore interface {
-	Post(id uuid.UUID) (Post, error)
-	Posts() ([]Post, error)
-	PostsByThead(threadID uuid.UUID) ([]Post, error)
-	CreatePost(t *Post) error
-	UpdatePost(t *Post) error
-	DeletePost(id uuid.UUID) error
+	Post(ctx context.Context, id uuid.UUID) (Post, error)
+	Posts(ctx context.Context, l PostListing, p Page) ([]Post, error)
+	PostsByThead(ctx context.Context, threadID uuid.UUID, l PostListing, p Page) ([]Post, error)
+	// PostsByUser returns a user's posts, newest first.
+	PostsByUser(ctx context.Context, userID uuid.UUID, p Page) ([]Post, error)
+	// PostsByDomain returns the link posts to a domain.
+	PostsByDomain(ctx context.Context, domain string, l PostListing, p Page) ([]Post, error)
+	// PostByURL returns the newest post in a thread that links to url, or
+	// ErrNotFound if there is none.
+	PostByURL(ctx context.Context, threadID uuid.UUID, url string) (Post, error)
+	LockPost(ctx context.Context, id uuid.UUID, locked bool) error
+	PinPost(ctx context.Context, id uuid.UUID, pinned bool) error
+	// UnrenderedPosts returns the IDs and content of up to limit posts that
+	// have content but no HTML rendered from it, in order of ID, starting
+	// after the post with ID after.
+	UnrenderedPosts(ctx context.Context, after uuid.UUID, limit int) ([]Post, error)
+	// SetPostHTML saves the HTML rendered from a post's content without
+	// marking the post edited.
+	SetPostHTML(ctx context.Context, id uuid.UUID, html string) error
+	CreatePost(ctx context.Context, t *Post) error
+	UpdatePost(ctx context.Context, t *Post) error
+	DeletePost(ctx context.Context, id uuid.UUID) error
 }
 
 type CommentStore interface {
-	Comment(id uuid.UUID) (Comment, error)
-	CommentsByPost(postID uuid.UUID) ([]Comment, error)
-	CreateComment(t *Comment) error
-	UpdateComment(t *Comment) error
-	DeleteComment(id uuid.UUID) error
+	Comment(ctx context.Context, id uuid.UUID) (Comment, error)
+	CommentsByPost(ctx context.Context, postID uuid.UUID, p Page) ([]Comment, error)
+	// CommentsByUser returns a user's comments, newest first, with the title
+	// of the post each one is on.
+	CommentsByUser(ctx context.Context, userID uuid.UUID, p Page) ([]Comment, error)
+	// CommentTree returns comments on a post in depth-first order, with
+	// replies following their parent and siblings sorted by votes. Depth is
+	// 0 for top-level comments. The page applies to top-level comments; each
+	// one comes with all of its replies.
+	CommentTree(ctx context.Context, postID uuid.UUID, p Page) ([]Comment, error)
+	// UnrenderedComments is UnrenderedPosts for comments.
+	UnrenderedComments(ctx context.Context, after uuid.UUID, limit int) ([]Comment, error)
+	// SetCommentHTML is SetPostHTML for comments.
+	SetCommentHTML(ctx context.Context, id uuid.UUID, html string) error
+	CreateComment(ctx context.Context, t *Comment) error
+	UpdateComment(ctx context.Context, t *Comment) error
+	// DeleteComment removes a comment. A comment that has replies is
+	// replaced by a Deleted placeholder instead, and placeholders are removed
+	// once their last reply is.
+	DeleteComment(ctx context.Context, id uuid.UUID) error
+}
+
+// ModeratorStore keeps track of who moderates each thread.
+type ModeratorStore interface {
+	// Moderators returns a thread's moderators, longest-serving first.
+	Moderators(ctx context.Context, threadID uuid.UUID) ([]ThreadModerator, error)
+	IsModerator(ctx context.Context, threadID, userID uuid.UUID) (bool, error)
+	AddModerator(ctx context.Context, m *ThreadModerator) error
+	RemoveModerator(ctx context.Context, threadID, userID uuid.UUID) error
+}
+
+// BanStore keeps track of users banned from threads and suspended from the
+// site. Bans and suspensions that have expired are ignored.
+type BanStore interface {
+	// ThreadBan returns the user's ban from a thread, or ErrNotFound if they
+	// are not banned.
+	ThreadBan(ctx context.Context, threadID, userID uuid.UUID) (ThreadBan, error)
+	// ThreadBans returns the users banned from a thread, most recent first.
+	ThreadBans(ctx context.Context, threadID uuid.UUID) ([]ThreadBan, error)
+	// BanUser bans a user from a thread, replacing any ban they already
+	// have there.
+	BanUser(ctx context.Context, b *ThreadBan) error
+	UnbanUser(ctx context.Context, threadID, userID uuid.UUID) error
+	// Suspension returns the user's suspension, or ErrNotFound if they are
+	// not suspended.
+	Suspension(ctx context.Context, userID uuid.UUID) (Suspension, error)
+	// SuspendUser suspends a user, replacing any suspension they already
+	// have.
+	SuspendUser(ctx context.Context, s *Suspension) error
+	UnsuspendUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// ReportStore keeps the reports users make against posts and comments.
+type ReportStore interface {
+	// CreateReport records a report. Each user may report a post or comment
+	// once; reporting it again is a conflict.
+	CreateReport(ctx context.Context, r *Report) error
+	// ReportQueue returns the posts and comments in a thread that have been
+	// reported, most reported first. Deleted comments are left out.
+	ReportQueue(ctx context.Context, threadID uuid.UUID) ([]ReportedItem, error)
+	// ClearReports removes the reports against a post, or against one of its
+	// comments if commentID is set.
+	ClearReports(ctx context.Context, postID, commentID uuid.UUID) error
+}
+
+// ModLogStore keeps a log of moderator actions in each thread.
+type ModLogStore interface {
+	// ModLog returns the entries in a thread's log that match f, newest
+	// first.
+	ModLog(ctx context.Context, threadID uuid.UUID, f ModLogFilter, p Page) ([]ModLogEntry, error)
+	LogModAction(ctx context.Context, e *ModLogEntry) error
 }
 
 type UserStore interface {
-	User(id uuid.UUID) (User, error)
-	UserByUsername(username string) (User, error)
-	CreateUser(u *User) error
-	UpdateUser(u *User) error
-	DeleteUser(id uuid.UUID) error
+	User(ctx context.Context, id uuid.UUID) (User, error)
+	UserByUsername(ctx context.Context, username string) (User, error)
+	CreateUser(ctx context.Context, u *User) error
+	UpdateUser(ctx context.Context, u *User) error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+}
+
+// VoteStore keeps a ledger of one vote per user per post or comment. A vote
+// value is -1, 0 (no vote) or +1. Votes on someone else's content also change
+// the author's karma.
+type VoteStore interface {
+	PostVote(ctx context.Context, postID, userID uuid.UUID) (int, error)
+	VotePost(ctx context.Context, postID, userID uuid.UUID, value int) error
+	CommentVote(ctx context.Context, commentID, userID uuid.UUID) (int, error)
+	VoteComment(ctx context.Context, commentID, userID uuid.UUID, value int) error
+	// TogglePostVote is what clicking a vote arrow does: it casts value as
+	// the user's vote, or withdraws their vote if it already is value, and
+	// returns the vote they end up with. The vote is read and changed in one
+	// step, so two quick clicks withdraw the vote rather than cast it twice.
+	TogglePostVote(ctx context.Context, postID, userID uuid.UUID, value int) (int, error)
+	// ToggleCommentVote is TogglePostVote for comments.
+	ToggleCommentVote(ctx context.Context, commentID, userID uuid.UUID, value int) (int, error)
+}
+
+type APITokenStore interface {
+	APITokens(ctx context.Context, userID uuid.UUID) ([]APIToken, error)
+	// UseAPIToken returns the token with the given hash and records that it
+	// has just been used.
+	UseAPIToken(ctx context.Context, hash []byte) (APIToken, error)
+	CreateAPIToken(ctx context.Context, t *APIToken) error
+	DeleteAPIToken(ctx context.Context, id uuid.UUID) error
+}
+
+// SearchStore finds posts and comments by their text, best matches first.
+type SearchStore interface {
+	Search(ctx context.Context, q SearchQuery, limit, offset int) ([]SearchResult, error)
 }
 
 type Store interface {
 	ThreadStore
 	PostStore
 	CommentStore
+	ModeratorStore
+	ModLogStore
+	ReportStore
+	BanStore
 	UserStore
+	VoteStore
+	APITokenStore
+	SearchStore
+}
+
+// BlobStore keeps the files users upload, such as the images in image posts,
+// under keys chosen by the application. Keys are slash-separated paths like
+// "images/photo.png".
+type BlobStore interface {
+	// Blob opens the blob stored under key, or returns ErrNotFound if there
+	// is none. The caller must close it.
+	Blob(ctx context.Context, key string) (Blob, error)
+	// PutBlob stores what r reads under key, replacing any blob already
+	// there.
+	PutBlob(ctx context.Context, key string, r io.Reader) error
+	// DeleteBlob removes the blob stored under key, if there is one.
+	DeleteBlob(ctx context.Context, key string) error
+}
+
+type Blob struct {
+	io.ReadSeekCloser
+	Size    int64
+	ModTime time.Time
 }
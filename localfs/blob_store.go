@@ -0,0 +1,112 @@
+// Package localfs keeps blobs as files on the local filesystem.
+package localfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/blrobin2/goreddit"
+)
+
+// NewBlobStore returns a store that keeps each blob in a file under dir, at
+// the path given by its key. dir is created if it does not exist.
+func NewBlobStore(dir string) (*BlobStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating blob directory: %w", err)
+	}
+
+	return &BlobStore{dir: dir}, nil
+}
+
+type BlobStore struct {
+	dir string
+}
+
+func (s *BlobStore) Blob(ctx context.Context, key string) (goreddit.Blob, error) {
+	path, ok := s.path(key)
+	if !ok {
+		return goreddit.Blob{}, fmt.Errorf("error getting blob: %w", goreddit.ErrNotFound)
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return goreddit.Blob{}, fmt.Errorf("error getting blob: %w", goreddit.ErrNotFound)
+	}
+	if err != nil {
+		return goreddit.Blob{}, fmt.Errorf("error getting blob: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return goreddit.Blob{}, fmt.Errorf("error getting blob: %w", err)
+	}
+	if info.IsDir() {
+		f.Close()
+		return goreddit.Blob{}, fmt.Errorf("error getting blob: %w", goreddit.ErrNotFound)
+	}
+
+	return goreddit.Blob{ReadSeekCloser: f, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// PutBlob writes the blob to a temporary file first, so that readers never
+// see a partly written one.
+func (s *BlobStore) PutBlob(ctx context.Context, key string, r io.Reader) error {
+	path, ok := s.path(key)
+	if !ok {
+		return fmt.Errorf("error storing blob: invalid key %q", key)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error storing blob: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("error storing blob: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("error storing blob: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error storing blob: %w", err)
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return fmt.Errorf("error storing blob: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("error storing blob: %w", err)
+	}
+
+	return nil
+}
+
+func (s *BlobStore) DeleteBlob(ctx context.Context, key string) error {
+	path, ok := s.path(key)
+	if !ok {
+		return fmt.Errorf("error deleting blob: invalid key %q", key)
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error deleting blob: %w", err)
+	}
+
+	return nil
+}
+
+// path returns the file a key is kept in. Keys that could point outside the
+// store's directory are refused.
+func (s *BlobStore) path(key string) (string, bool) {
+	if key == "." || !fs.ValidPath(key) {
+		return "", false
+	}
+
+	return filepath.Join(s.dir, filepath.FromSlash(key)), true
+}
@@ -0,0 +1,20 @@
+package localfs_test
+
+import (
+	"testing"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/blrobin2/goreddit/localfs"
+	"github.com/blrobin2/goreddit/storetest"
+)
+
+func TestBlobStore(t *testing.T) {
+	storetest.RunBlobStore(t, func(t *testing.T) goreddit.BlobStore {
+		s, err := localfs.NewBlobStore(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return s
+	})
+}
@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+type APITokenStore struct {
+	*db
+}
+
+func (s *APITokenStore) APITokens(ctx context.Context, userID uuid.UUID) ([]goreddit.APIToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ts := []goreddit.APIToken{}
+	for _, t := range s.tokens {
+		if t.UserID == userID {
+			ts = append(ts, t)
+		}
+	}
+	sort.Slice(ts, func(i, j int) bool {
+		return ts[i].CreatedAt.After(ts[j].CreatedAt)
+	})
+
+	return ts, nil
+}
+
+func (s *APITokenStore) UseAPIToken(ctx context.Context, hash []byte) (goreddit.APIToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, t := range s.tokens {
+		if bytes.Equal(t.Hash, hash) {
+			used := now()
+			t.LastUsedAt = &used
+			s.tokens[id] = t
+			return t, nil
+		}
+	}
+
+	return goreddit.APIToken{}, fmt.Errorf("error getting api token: %w", goreddit.ErrNotFound)
+}
+
+func (s *APITokenStore) CreateAPIToken(ctx context.Context, t *goreddit.APIToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tokens[t.ID]; ok {
+		return fmt.Errorf("error creating api token: %w", goreddit.ErrConflict)
+	}
+	for _, other := range s.tokens {
+		if bytes.Equal(other.Hash, t.Hash) {
+			return fmt.Errorf("error creating api token: %w", goreddit.ErrConflict)
+		}
+	}
+	if _, ok := s.users[t.UserID]; !ok {
+		return fmt.Errorf("error creating api token: %w", goreddit.ErrNotFound)
+	}
+
+	t.CreatedAt = now()
+	t.LastUsedAt = nil
+	t.Hash = append([]byte(nil), t.Hash...)
+	s.tokens[t.ID] = *t
+
+	return nil
+}
+
+func (s *APITokenStore) DeleteAPIToken(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tokens, id)
+
+	return nil
+}
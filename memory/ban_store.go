@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+type BanStore struct {
+	*db
+}
+
+func (s *BanStore) ThreadBan(ctx context.Context, threadID, userID uuid.UUID) (goreddit.ThreadBan, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	b, ok := s.threadBans[threadUserKey{threadID: threadID, userID: userID}]
+	if !ok || expired(b.ExpiresAt) {
+		return goreddit.ThreadBan{}, fmt.Errorf("error getting thread ban: %w", goreddit.ErrNotFound)
+	}
+	b.Username = s.username(b.UserID)
+
+	return b, nil
+}
+
+func (s *BanStore) ThreadBans(ctx context.Context, threadID uuid.UUID) ([]goreddit.ThreadBan, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bs := []goreddit.ThreadBan{}
+	for k, b := range s.threadBans {
+		if k.threadID == threadID && !expired(b.ExpiresAt) {
+			b.Username = s.username(b.UserID)
+			bs = append(bs, b)
+		}
+	}
+	sort.Slice(bs, func(i, j int) bool {
+		if !bs[i].CreatedAt.Equal(bs[j].CreatedAt) {
+			return bs[i].CreatedAt.After(bs[j].CreatedAt)
+		}
+		return bytes.Compare(bs[i].UserID[:], bs[j].UserID[:]) > 0
+	})
+
+	return bs, nil
+}
+
+func (s *BanStore) BanUser(ctx context.Context, b *goreddit.ThreadBan) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.threads[b.ThreadID]; !ok || b.UserID == uuid.Nil || !s.userExists(b.UserID) || !s.userExists(b.BannedBy) {
+		return fmt.Errorf("error banning user: %w", goreddit.ErrNotFound)
+	}
+
+	b.CreatedAt = now()
+	stored := *b
+	stored.Username = ""
+	s.threadBans[threadUserKey{threadID: b.ThreadID, userID: b.UserID}] = stored
+
+	return nil
+}
+
+func (s *BanStore) UnbanUser(ctx context.Context, threadID, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := threadUserKey{threadID: threadID, userID: userID}
+	if _, ok := s.threadBans[k]; !ok {
+		return fmt.Errorf("error unbanning user: %w", goreddit.ErrNotFound)
+	}
+	delete(s.threadBans, k)
+
+	return nil
+}
+
+func (s *BanStore) Suspension(ctx context.Context, userID uuid.UUID) (goreddit.Suspension, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	su, ok := s.suspensions[userID]
+	if !ok || expired(su.ExpiresAt) {
+		return goreddit.Suspension{}, fmt.Errorf("error getting suspension: %w", goreddit.ErrNotFound)
+	}
+
+	return su, nil
+}
+
+func (s *BanStore) SuspendUser(ctx context.Context, su *goreddit.Suspension) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if su.UserID == uuid.Nil || !s.userExists(su.UserID) || !s.userExists(su.SuspendedBy) {
+		return fmt.Errorf("error suspending user: %w", goreddit.ErrNotFound)
+	}
+
+	su.CreatedAt = now()
+	s.suspensions[su.UserID] = *su
+
+	return nil
+}
+
+func (s *BanStore) UnsuspendUser(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.suspensions[userID]; !ok {
+		return fmt.Errorf("error unsuspending user: %w", goreddit.ErrNotFound)
+	}
+	delete(s.suspensions, userID)
+
+	return nil
+}
+
+// expired reports whether a ban or suspension ending at expiresAt is over.
+func expired(expiresAt *time.Time) bool {
+	return expiresAt != nil && !expiresAt.After(now())
+}
@@ -0,0 +1,261 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+type CommentStore struct {
+	*db
+}
+
+func (s *CommentStore) Comment(ctx context.Context, id uuid.UUID) (goreddit.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.comments[id]
+	if !ok {
+		return goreddit.Comment{}, fmt.Errorf("error getting comment: %w", goreddit.ErrNotFound)
+	}
+	c.Username = s.username(c.UserID)
+
+	return c, nil
+}
+
+func (s *CommentStore) CommentsByPost(ctx context.Context, postID uuid.UUID, p goreddit.Page) ([]goreddit.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	es := []entry{}
+	for _, c := range s.comments {
+		if c.PostID == postID {
+			es = append(es, entry{key: commentKey(c), id: c.ID})
+		}
+	}
+
+	cs := []goreddit.Comment{}
+	for _, e := range selectPage(es, p, s.commentCursor) {
+		c := s.comments[e.id]
+		c.Username = s.username(c.UserID)
+		cs = append(cs, c)
+	}
+
+	return cs, nil
+}
+
+func (s *CommentStore) CommentsByUser(ctx context.Context, userID uuid.UUID, p goreddit.Page) ([]goreddit.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	es := []entry{}
+	for _, c := range s.comments {
+		if userID != uuid.Nil && c.UserID == userID {
+			es = append(es, entry{key: sortKey{time: c.CreatedAt, id: c.ID}, id: c.ID})
+		}
+	}
+
+	cursor := func(id uuid.UUID) (sortKey, bool) {
+		c, ok := s.comments[id]
+		return sortKey{time: c.CreatedAt, id: c.ID}, ok
+	}
+
+	cs := []goreddit.Comment{}
+	for _, e := range selectPage(es, p, cursor) {
+		c := s.comments[e.id]
+		c.Username = s.username(c.UserID)
+		c.PostTitle = s.posts[c.PostID].Title
+		cs = append(cs, c)
+	}
+
+	return cs, nil
+}
+
+func (s *CommentStore) CommentTree(ctx context.Context, postID uuid.UUID, p goreddit.Page) ([]goreddit.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	roots := []entry{}
+	replies := map[uuid.UUID][]goreddit.Comment{}
+	for _, c := range s.comments {
+		if c.PostID != postID {
+			continue
+		}
+		if c.ParentID == uuid.Nil {
+			roots = append(roots, entry{key: commentKey(c), id: c.ID})
+		} else {
+			replies[c.ParentID] = append(replies[c.ParentID], c)
+		}
+	}
+
+	cs := []goreddit.Comment{}
+	var walk func(c goreddit.Comment, depth int)
+	walk = func(c goreddit.Comment, depth int) {
+		c.Depth = depth
+		c.Username = s.username(c.UserID)
+		cs = append(cs, c)
+
+		children := replies[c.ID]
+		sort.Slice(children, func(i, j int) bool {
+			return commentKey(children[j]).less(commentKey(children[i]))
+		})
+		for _, child := range children {
+			walk(child, depth+1)
+		}
+	}
+
+	for _, e := range selectPage(roots, p, s.commentCursor) {
+		walk(s.comments[e.id], 0)
+	}
+
+	return cs, nil
+}
+
+func (s *CommentStore) UnrenderedComments(ctx context.Context, after uuid.UUID, limit int) ([]goreddit.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ns := []goreddit.Comment{}
+	for _, n := range s.comments {
+		if n.Content != "" && n.ContentHTML == "" && n.ID.String() > after.String() {
+			ns = append(ns, goreddit.Comment{ID: n.ID, Content: n.Content})
+		}
+	}
+	sort.Slice(ns, func(i, j int) bool { return ns[i].ID.String() < ns[j].ID.String() })
+	if len(ns) > limit {
+		ns = ns[:limit]
+	}
+
+	return ns, nil
+}
+
+func (s *CommentStore) SetCommentHTML(ctx context.Context, id uuid.UUID, html string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.comments[id]
+	if !ok {
+		return fmt.Errorf("error updating comment: %w", goreddit.ErrNotFound)
+	}
+	n.ContentHTML = html
+	s.comments[id] = n
+
+	return nil
+}
+
+func (s *CommentStore) CreateComment(ctx context.Context, c *goreddit.Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.comments[c.ID]; ok {
+		return fmt.Errorf("error creating comment: %w", goreddit.ErrConflict)
+	}
+	if _, ok := s.posts[c.PostID]; !ok || !s.userExists(c.UserID) {
+		return fmt.Errorf("error creating comment: %w", goreddit.ErrNotFound)
+	}
+	if _, ok := s.comments[c.ParentID]; c.ParentID != uuid.Nil && !ok {
+		return fmt.Errorf("error creating comment: %w", goreddit.ErrNotFound)
+	}
+
+	stored := goreddit.Comment{
+		ID:          c.ID,
+		PostID:      c.PostID,
+		UserID:      c.UserID,
+		ParentID:    c.ParentID,
+		Content:     c.Content,
+		ContentHTML: c.ContentHTML,
+		Votes:       c.Votes,
+		CreatedAt:   now(),
+	}
+	stored.UpdatedAt = stored.CreatedAt
+	s.comments[c.ID] = stored
+	returningComment(stored, c)
+
+	return nil
+}
+
+func (s *CommentStore) UpdateComment(ctx context.Context, c *goreddit.Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.comments[c.ID]
+	if !ok {
+		return fmt.Errorf("error updating comment: %w", goreddit.ErrNotFound)
+	}
+	if _, ok := s.posts[c.PostID]; !ok {
+		return fmt.Errorf("error updating comment: %w", goreddit.ErrNotFound)
+	}
+
+	stored.PostID = c.PostID
+	stored.Content = c.Content
+	stored.ContentHTML = c.ContentHTML
+	stored.EditedAt = c.EditedAt
+	stored.UpdatedAt = now()
+	s.comments[c.ID] = stored
+	returningComment(stored, c)
+
+	return nil
+}
+
+func (s *CommentStore) DeleteComment(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.comments[id]
+	if !ok {
+		return nil
+	}
+
+	if s.hasReplies(id) {
+		c.Content = ""
+		c.ContentHTML = ""
+		c.UserID = uuid.Nil
+		c.Deleted = true
+		c.UpdatedAt = now()
+		s.comments[id] = c
+		return nil
+	}
+
+	// Remove the comment, then any placeholders above it that were only kept
+	// for its sake.
+	for {
+		s.deleteComment(c.ID)
+		parent, ok := s.comments[c.ParentID]
+		if !ok || !parent.Deleted || s.hasReplies(parent.ID) {
+			break
+		}
+		c = parent
+	}
+
+	return nil
+}
+
+func (s *CommentStore) hasReplies(id uuid.UUID) bool {
+	for _, c := range s.comments {
+		if c.ParentID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// returningComment copies a stored row into c the way an SQL RETURNING
+// clause would, leaving the fields that come from joins alone.
+func returningComment(stored goreddit.Comment, c *goreddit.Comment) {
+	stored.Username = c.Username
+	stored.Depth = c.Depth
+	stored.PostTitle = c.PostTitle
+	*c = stored
+}
+
+func commentKey(c goreddit.Comment) sortKey {
+	return sortKey{score: float64(c.Votes), id: c.ID}
+}
+
+func (s *CommentStore) commentCursor(id uuid.UUID) (sortKey, bool) {
+	c, ok := s.comments[id]
+	return commentKey(c), ok
+}
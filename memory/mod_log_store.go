@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+type ModLogStore struct {
+	*db
+}
+
+func (s *ModLogStore) ModLog(ctx context.Context, threadID uuid.UUID, f goreddit.ModLogFilter, p goreddit.Page) ([]goreddit.ModLogEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	es := []entry{}
+	for _, e := range s.modLog {
+		if e.ThreadID != threadID {
+			continue
+		}
+		if f.Action != "" && e.Action != f.Action {
+			continue
+		}
+		if f.ActorID != uuid.Nil && e.ActorID != f.ActorID {
+			continue
+		}
+		es = append(es, entry{key: modLogKey(e), id: e.ID})
+	}
+
+	cursor := func(id uuid.UUID) (sortKey, bool) {
+		e, ok := s.modLog[id]
+		return modLogKey(e), ok
+	}
+
+	log := []goreddit.ModLogEntry{}
+	for _, e := range selectPage(es, p, cursor) {
+		entry := s.modLog[e.id]
+		entry.ActorName = s.username(entry.ActorID)
+		entry.TargetName = s.username(entry.TargetUserID)
+		log = append(log, entry)
+	}
+
+	return log, nil
+}
+
+func (s *ModLogStore) LogModAction(ctx context.Context, e *goreddit.ModLogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.modLog[e.ID]; ok {
+		return fmt.Errorf("error logging mod action: %w", goreddit.ErrConflict)
+	}
+	if _, ok := s.threads[e.ThreadID]; !ok || !s.userExists(e.ActorID) || !s.userExists(e.TargetUserID) {
+		return fmt.Errorf("error logging mod action: %w", goreddit.ErrNotFound)
+	}
+
+	e.CreatedAt = now()
+	stored := *e
+	stored.ActorName, stored.TargetName = "", ""
+	s.modLog[e.ID] = stored
+
+	return nil
+}
+
+func modLogKey(e goreddit.ModLogEntry) sortKey {
+	return sortKey{time: e.CreatedAt, id: e.ID}
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+type ModeratorStore struct {
+	*db
+}
+
+func (s *ModeratorStore) Moderators(ctx context.Context, threadID uuid.UUID) ([]goreddit.ThreadModerator, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ms := []goreddit.ThreadModerator{}
+	for k, m := range s.moderators {
+		if k.threadID == threadID {
+			m.Username = s.username(m.UserID)
+			ms = append(ms, m)
+		}
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		if !ms[i].CreatedAt.Equal(ms[j].CreatedAt) {
+			return ms[i].CreatedAt.Before(ms[j].CreatedAt)
+		}
+		return bytes.Compare(ms[i].UserID[:], ms[j].UserID[:]) < 0
+	})
+
+	return ms, nil
+}
+
+func (s *ModeratorStore) IsModerator(ctx context.Context, threadID, userID uuid.UUID) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.moderators[threadUserKey{threadID: threadID, userID: userID}]
+	return ok, nil
+}
+
+func (s *ModeratorStore) AddModerator(ctx context.Context, m *goreddit.ThreadModerator) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := threadUserKey{threadID: m.ThreadID, userID: m.UserID}
+	if _, ok := s.moderators[k]; ok {
+		return fmt.Errorf("error adding moderator: %w", goreddit.ErrConflict)
+	}
+	if _, ok := s.threads[m.ThreadID]; !ok || m.UserID == uuid.Nil || !s.userExists(m.UserID) {
+		return fmt.Errorf("error adding moderator: %w", goreddit.ErrNotFound)
+	}
+
+	m.CreatedAt = now()
+	s.moderators[k] = goreddit.ThreadModerator{ThreadID: m.ThreadID, UserID: m.UserID, CreatedAt: m.CreatedAt}
+
+	return nil
+}
+
+func (s *ModeratorStore) RemoveModerator(ctx context.Context, threadID, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := threadUserKey{threadID: threadID, userID: userID}
+	if _, ok := s.moderators[k]; !ok {
+		return fmt.Errorf("error removing moderator: %w", goreddit.ErrNotFound)
+	}
+	delete(s.moderators, k)
+
+	return nil
+}
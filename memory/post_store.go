@@ -0,0 +1,317 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+type PostStore struct {
+	*db
+}
+
+func (s *PostStore) Post(ctx context.Context, id uuid.UUID) (goreddit.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return goreddit.Post{}, fmt.Errorf("error getting post: %w", goreddit.ErrNotFound)
+	}
+	p.Username = s.username(p.UserID)
+
+	return p, nil
+}
+
+func (s *PostStore) PostsByThead(ctx context.Context, threadID uuid.UUID, l goreddit.PostListing, p goreddit.Page) ([]goreddit.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ps := s.listPosts(l, p, true, func(post goreddit.Post) bool {
+		return post.ThreadID == threadID
+	})
+	for i := range ps {
+		ps[i].ThreadTitle = ""
+	}
+
+	return ps, nil
+}
+
+func (s *PostStore) Posts(ctx context.Context, l goreddit.PostListing, p goreddit.Page) ([]goreddit.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.listPosts(l, p, false, func(goreddit.Post) bool { return true }), nil
+}
+
+func (s *PostStore) PostsByDomain(ctx context.Context, domain string, l goreddit.PostListing, p goreddit.Page) ([]goreddit.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.listPosts(l, p, false, func(post goreddit.Post) bool {
+		return domain != "" && post.Domain == domain
+	}), nil
+}
+
+func (s *PostStore) PostByURL(ctx context.Context, threadID uuid.UUID, url string) (goreddit.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found goreddit.Post
+	for _, p := range s.posts {
+		if url != "" && p.ThreadID == threadID && p.URL == url && p.CreatedAt.After(found.CreatedAt) {
+			found = p
+		}
+	}
+	if found.ID == uuid.Nil {
+		return goreddit.Post{}, fmt.Errorf("error getting post: %w", goreddit.ErrNotFound)
+	}
+	found.Username = s.username(found.UserID)
+
+	return found, nil
+}
+
+func (s *PostStore) PostsByUser(ctx context.Context, userID uuid.UUID, p goreddit.Page) ([]goreddit.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	l := goreddit.PostListing{Sort: goreddit.SortNew}
+	return s.listPosts(l, p, false, func(post goreddit.Post) bool {
+		return userID != uuid.Nil && post.UserID == userID
+	}), nil
+}
+
+// listPosts returns page p of the posts that include accepts, in listing
+// order. Thread listings put pinned posts first.
+func (s *PostStore) listPosts(l goreddit.PostListing, p goreddit.Page, pinnedFirst bool, include func(goreddit.Post) bool) []goreddit.Post {
+	since := listingSince(l)
+	key := func(post goreddit.Post) sortKey {
+		k := postKey(l.Sort, post)
+		k.pinned = pinnedFirst && post.Pinned
+		return k
+	}
+
+	es := []entry{}
+	for _, post := range s.posts {
+		if include(post) && !post.CreatedAt.Before(since) {
+			es = append(es, entry{key: key(post), id: post.ID})
+		}
+	}
+
+	cursor := func(id uuid.UUID) (sortKey, bool) {
+		post, ok := s.posts[id]
+		return key(post), ok
+	}
+
+	ps := []goreddit.Post{}
+	for _, e := range selectPage(es, p, cursor) {
+		post := s.posts[e.id]
+		post.ThreadTitle = s.threads[post.ThreadID].Title
+		post.Username = s.username(post.UserID)
+		for _, c := range s.comments {
+			if c.PostID == post.ID {
+				post.CommentsCount++
+			}
+		}
+		ps = append(ps, post)
+	}
+
+	return ps
+}
+
+func (s *PostStore) UnrenderedPosts(ctx context.Context, after uuid.UUID, limit int) ([]goreddit.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ns := []goreddit.Post{}
+	for _, n := range s.posts {
+		if n.Content != "" && n.ContentHTML == "" && n.ID.String() > after.String() {
+			ns = append(ns, goreddit.Post{ID: n.ID, Content: n.Content})
+		}
+	}
+	sort.Slice(ns, func(i, j int) bool { return ns[i].ID.String() < ns[j].ID.String() })
+	if len(ns) > limit {
+		ns = ns[:limit]
+	}
+
+	return ns, nil
+}
+
+func (s *PostStore) SetPostHTML(ctx context.Context, id uuid.UUID, html string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.posts[id]
+	if !ok {
+		return fmt.Errorf("error updating post: %w", goreddit.ErrNotFound)
+	}
+	n.ContentHTML = html
+	s.posts[id] = n
+
+	return nil
+}
+
+func (s *PostStore) CreatePost(ctx context.Context, p *goreddit.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.posts[p.ID]; ok {
+		return fmt.Errorf("error creating post: %w", goreddit.ErrConflict)
+	}
+	if _, ok := s.threads[p.ThreadID]; !ok || !s.userExists(p.UserID) {
+		return fmt.Errorf("error creating post: %w", goreddit.ErrNotFound)
+	}
+
+	stored := goreddit.Post{
+		ID:          p.ID,
+		ThreadID:    p.ThreadID,
+		UserID:      p.UserID,
+		Title:       p.Title,
+		Content:     p.Content,
+		ContentHTML: p.ContentHTML,
+		Kind:        postKind(p.Kind),
+		URL:         p.URL,
+		Domain:      p.Domain,
+		Image:       p.Image,
+		Thumbnail:   p.Thumbnail,
+		Votes:       p.Votes,
+		CreatedAt:   now(),
+	}
+	stored.UpdatedAt = stored.CreatedAt
+	s.posts[p.ID] = stored
+	returningPost(stored, p)
+
+	return nil
+}
+
+func (s *PostStore) UpdatePost(ctx context.Context, p *goreddit.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.posts[p.ID]
+	if !ok {
+		return fmt.Errorf("error updating post: %w", goreddit.ErrNotFound)
+	}
+	if _, ok := s.threads[p.ThreadID]; !ok {
+		return fmt.Errorf("error updating post: %w", goreddit.ErrNotFound)
+	}
+
+	stored.ThreadID = p.ThreadID
+	stored.Title = p.Title
+	stored.Content = p.Content
+	stored.ContentHTML = p.ContentHTML
+	stored.Kind = postKind(p.Kind)
+	stored.URL = p.URL
+	stored.Domain = p.Domain
+	stored.Image = p.Image
+	stored.Thumbnail = p.Thumbnail
+	stored.EditedAt = p.EditedAt
+	stored.UpdatedAt = now()
+	s.posts[p.ID] = stored
+	returningPost(stored, p)
+
+	return nil
+}
+
+func (s *PostStore) LockPost(ctx context.Context, id uuid.UUID, locked bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return fmt.Errorf("error locking post: %w", goreddit.ErrNotFound)
+	}
+	p.Locked = locked
+	s.posts[id] = p
+
+	return nil
+}
+
+func (s *PostStore) PinPost(ctx context.Context, id uuid.UUID, pinned bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return fmt.Errorf("error pinning post: %w", goreddit.ErrNotFound)
+	}
+	p.Pinned = pinned
+	s.posts[id] = p
+
+	return nil
+}
+
+func (s *PostStore) DeletePost(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deletePost(id)
+
+	return nil
+}
+
+// returningPost copies a stored row into p the way an SQL RETURNING clause
+// would, leaving the fields that come from joins alone.
+func returningPost(stored goreddit.Post, p *goreddit.Post) {
+	stored.CommentsCount = p.CommentsCount
+	stored.ThreadTitle = p.ThreadTitle
+	stored.Username = p.Username
+	*p = stored
+}
+
+func postKey(sort goreddit.PostSort, p goreddit.Post) sortKey {
+	return sortKey{score: postScore(sort, p), id: p.ID}
+}
+
+// postScore ranks posts the same way as the Postgres store.
+func postScore(sort goreddit.PostSort, p goreddit.Post) float64 {
+	epoch := float64(p.CreatedAt.UnixNano()) / float64(time.Second)
+
+	switch sort {
+	case goreddit.SortNew:
+		return epoch
+	case goreddit.SortTop:
+		return float64(p.Votes)
+	case goreddit.SortControversial:
+		if p.Upvotes == 0 || p.Downvotes == 0 {
+			return 0
+		}
+		up, down := float64(p.Upvotes), float64(p.Downvotes)
+		balance := up / down
+		if up > down {
+			balance = down / up
+		}
+		return math.Pow(up+down, balance)
+	default:
+		sign := 0.0
+		if p.Votes > 0 {
+			sign = 1
+		} else if p.Votes < 0 {
+			sign = -1
+		}
+		return sign*math.Log10(math.Max(math.Abs(float64(p.Votes)), 1)) + epoch/45000
+	}
+}
+
+// listingSince returns the oldest creation time included in a listing.
+func listingSince(l goreddit.PostListing) time.Time {
+	if l.Window == 0 || (l.Sort != goreddit.SortTop && l.Sort != goreddit.SortControversial) {
+		return time.Time{}
+	}
+
+	return time.Now().Add(-l.Window)
+}
+
+// postKind returns kind, or PostText for posts saved without one.
+func postKind(kind goreddit.PostKind) goreddit.PostKind {
+	if kind == "" {
+		return goreddit.PostText
+	}
+
+	return kind
+}
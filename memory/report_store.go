@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+type ReportStore struct {
+	*db
+}
+
+func (s *ReportStore) CreateReport(ctx context.Context, r *goreddit.Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.reports[r.ID]; ok {
+		return fmt.Errorf("error creating report: %w", goreddit.ErrConflict)
+	}
+	if _, ok := s.posts[r.PostID]; !ok || !s.userExists(r.UserID) {
+		return fmt.Errorf("error creating report: %w", goreddit.ErrNotFound)
+	}
+	if _, ok := s.comments[r.CommentID]; r.CommentID != uuid.Nil && !ok {
+		return fmt.Errorf("error creating report: %w", goreddit.ErrNotFound)
+	}
+	for _, o := range s.reports {
+		if r.UserID != uuid.Nil && o.UserID == r.UserID && o.PostID == r.PostID && o.CommentID == r.CommentID {
+			return fmt.Errorf("error creating report: %w", goreddit.ErrConflict)
+		}
+	}
+
+	r.CreatedAt = now()
+	s.reports[r.ID] = *r
+
+	return nil
+}
+
+func (s *ReportStore) ReportQueue(ctx context.Context, threadID uuid.UUID) ([]goreddit.ReportedItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rs := []goreddit.Report{}
+	for _, r := range s.reports {
+		if s.posts[r.PostID].ThreadID == threadID && !s.comments[r.CommentID].Deleted {
+			rs = append(rs, r)
+		}
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		if !rs[i].CreatedAt.Equal(rs[j].CreatedAt) {
+			return rs[i].CreatedAt.Before(rs[j].CreatedAt)
+		}
+		return bytes.Compare(rs[i].ID[:], rs[j].ID[:]) < 0
+	})
+
+	type key struct{ postID, commentID uuid.UUID }
+	index := map[key]int{}
+	items := []goreddit.ReportedItem{}
+	for _, r := range rs {
+		k := key{r.PostID, r.CommentID}
+		i, ok := index[k]
+		if !ok {
+			i = len(items)
+			index[k] = i
+			items = append(items, s.reportedItem(r))
+		}
+		items[i].Reports = append(items[i].Reports, r)
+	}
+
+	// Reports are oldest first, so the last one is the latest.
+	latest := func(i goreddit.ReportedItem) goreddit.Report { return i.Reports[len(i.Reports)-1] }
+	sort.SliceStable(items, func(i, j int) bool {
+		if len(items[i].Reports) != len(items[j].Reports) {
+			return len(items[i].Reports) > len(items[j].Reports)
+		}
+		return latest(items[i]).CreatedAt.After(latest(items[j]).CreatedAt)
+	})
+
+	return items, nil
+}
+
+func (s *ReportStore) reportedItem(r goreddit.Report) goreddit.ReportedItem {
+	p := s.posts[r.PostID]
+	item := goreddit.ReportedItem{
+		PostID:    p.ID,
+		PostTitle: p.Title,
+		Content:   p.Content,
+		UserID:    p.UserID,
+	}
+	if c, ok := s.comments[r.CommentID]; ok {
+		item.CommentID = c.ID
+		item.Content = c.Content
+		item.UserID = c.UserID
+	}
+	item.Username = s.username(item.UserID)
+
+	return item
+}
+
+func (s *ReportStore) ClearReports(ctx context.Context, postID, commentID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, r := range s.reports {
+		if r.PostID == postID && r.CommentID == commentID {
+			delete(s.reports, id)
+		}
+	}
+
+	return nil
+}
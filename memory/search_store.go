@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+// snippetWords is how many words of context a search snippet shows.
+const snippetWords = 30
+
+type SearchStore struct {
+	*db
+}
+
+// Search matches whole words, ignoring case. Unlike the SQL stores it does no
+// stemming, so "vote" does not find "voting".
+func (s *SearchStore) Search(ctx context.Context, q goreddit.SearchQuery, limit, offset int) ([]goreddit.SearchResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var phrases [][]string
+	for _, p := range q.Phrases {
+		if words := normalizedWords(p); len(words) > 0 {
+			phrases = append(phrases, words)
+		}
+	}
+	if len(phrases) == 0 {
+		return []goreddit.SearchResult{}, nil
+	}
+
+	include := func(threadID, userID uuid.UUID) bool {
+		return (q.Thread == "" || strings.EqualFold(s.threads[threadID].Title, q.Thread)) &&
+			(q.Author == "" || strings.EqualFold(s.username(userID), q.Author))
+	}
+
+	rs := []goreddit.SearchResult{}
+	for _, p := range s.posts {
+		if !include(p.ThreadID, p.UserID) {
+			continue
+		}
+		title, content := strings.Fields(p.Title), strings.Fields(p.Content)
+		rank, ok := 0.0, true
+		for _, phrase := range phrases {
+			n := 2*len(matches(title, phrase)) + len(matches(content, phrase))
+			if n == 0 {
+				ok = false
+				break
+			}
+			rank += float64(n)
+		}
+		if !ok {
+			continue
+		}
+		rs = append(rs, goreddit.SearchResult{
+			PostID:      p.ID,
+			ThreadID:    p.ThreadID,
+			ThreadTitle: s.threads[p.ThreadID].Title,
+			PostTitle:   p.Title,
+			Username:    s.username(p.UserID),
+			CreatedAt:   p.CreatedAt,
+			Snippet:     snippet(content, phrases),
+			Rank:        rank,
+		})
+	}
+
+	for _, c := range s.comments {
+		p := s.posts[c.PostID]
+		if !include(p.ThreadID, c.UserID) {
+			continue
+		}
+		content := strings.Fields(c.Content)
+		rank, ok := 0.0, true
+		for _, phrase := range phrases {
+			n := len(matches(content, phrase))
+			if n == 0 {
+				ok = false
+				break
+			}
+			rank += float64(n)
+		}
+		if !ok {
+			continue
+		}
+		rs = append(rs, goreddit.SearchResult{
+			PostID:      p.ID,
+			CommentID:   c.ID,
+			ThreadID:    p.ThreadID,
+			ThreadTitle: s.threads[p.ThreadID].Title,
+			PostTitle:   p.Title,
+			Username:    s.username(c.UserID),
+			CreatedAt:   c.CreatedAt,
+			Snippet:     snippet(content, phrases),
+			Rank:        rank,
+		})
+	}
+
+	sort.Slice(rs, func(i, j int) bool {
+		if rs[i].Rank != rs[j].Rank {
+			return rs[i].Rank > rs[j].Rank
+		}
+		return rs[i].CreatedAt.After(rs[j].CreatedAt)
+	})
+
+	if offset > len(rs) {
+		offset = len(rs)
+	}
+	rs = rs[offset:]
+	if limit > 0 && len(rs) > limit {
+		rs = rs[:limit]
+	}
+
+	return rs, nil
+}
+
+// matches returns the indexes in words at which phrase starts.
+func matches(words []string, phrase []string) []int {
+	var at []int
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		found := true
+		for j, w := range phrase {
+			if normalize(words[i+j]) != w {
+				found = false
+				break
+			}
+		}
+		if found {
+			at = append(at, i)
+		}
+	}
+	return at
+}
+
+// snippet returns the words around the first match, with every match marked.
+func snippet(words []string, phrases [][]string) string {
+	marked := make([]bool, len(words))
+	first := len(words)
+	for _, phrase := range phrases {
+		for _, i := range matches(words, phrase) {
+			for j := range phrase {
+				marked[i+j] = true
+			}
+			if i < first {
+				first = i
+			}
+		}
+	}
+	if first == len(words) {
+		first = 0
+	}
+
+	start := first - snippetWords/3
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetWords
+	if end > len(words) {
+		end = len(words)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("… ")
+	}
+	for i := start; i < end; i++ {
+		if i > start {
+			b.WriteByte(' ')
+		}
+		if marked[i] && (i == start || !marked[i-1]) {
+			b.WriteString(goreddit.MatchStart)
+		}
+		b.WriteString(words[i])
+		if marked[i] && (i == end-1 || !marked[i+1]) {
+			b.WriteString(goreddit.MatchEnd)
+		}
+	}
+	if end < len(words) {
+		b.WriteString(" …")
+	}
+
+	return b.String()
+}
+
+func normalizedWords(s string) []string {
+	var words []string
+	for _, w := range strings.Fields(s) {
+		if w = normalize(w); w != "" {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// normalize lowercases a word and strips the punctuation around it.
+func normalize(w string) string {
+	return strings.ToLower(strings.TrimFunc(w, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}))
+}
@@ -0,0 +1,210 @@
+package memory
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+// NewStore returns an empty store that keeps everything in memory. It
+// behaves like the Postgres store, including cascading deletes, and is safe
+// for concurrent use.
+func NewStore() *Store {
+	d := &db{
+		threads:      map[uuid.UUID]goreddit.Thread{},
+		posts:        map[uuid.UUID]goreddit.Post{},
+		comments:     map[uuid.UUID]goreddit.Comment{},
+		users:        map[uuid.UUID]goreddit.User{},
+		postVotes:    map[voteKey]int{},
+		commentVotes: map[voteKey]int{},
+		tokens:       map[uuid.UUID]goreddit.APIToken{},
+		moderators:   map[threadUserKey]goreddit.ThreadModerator{},
+		modLog:       map[uuid.UUID]goreddit.ModLogEntry{},
+		reports:      map[uuid.UUID]goreddit.Report{},
+		threadBans:   map[threadUserKey]goreddit.ThreadBan{},
+		suspensions:  map[uuid.UUID]goreddit.Suspension{},
+	}
+
+	return &Store{
+		ThreadStore:    &ThreadStore{db: d},
+		PostStore:      &PostStore{db: d},
+		CommentStore:   &CommentStore{db: d},
+		ModeratorStore: &ModeratorStore{db: d},
+		ModLogStore:    &ModLogStore{db: d},
+		ReportStore:    &ReportStore{db: d},
+		BanStore:       &BanStore{db: d},
+		UserStore:      &UserStore{db: d},
+		VoteStore:      &VoteStore{db: d},
+		APITokenStore:  &APITokenStore{db: d},
+		SearchStore:    &SearchStore{db: d},
+	}
+}
+
+type Store struct {
+	*ThreadStore
+	*PostStore
+	*CommentStore
+	*ModeratorStore
+	*ModLogStore
+	*ReportStore
+	*BanStore
+	*UserStore
+	*VoteStore
+	*APITokenStore
+	*SearchStore
+}
+
+// db holds the rows of every table. Rows are stored by value and only
+// contain their own columns; joined fields such as a post's author name are
+// filled in when they are read.
+type db struct {
+	mu sync.RWMutex
+
+	threads      map[uuid.UUID]goreddit.Thread
+	posts        map[uuid.UUID]goreddit.Post
+	comments     map[uuid.UUID]goreddit.Comment
+	users        map[uuid.UUID]goreddit.User
+	postVotes    map[voteKey]int
+	commentVotes map[voteKey]int
+	tokens       map[uuid.UUID]goreddit.APIToken
+	moderators   map[threadUserKey]goreddit.ThreadModerator
+	modLog       map[uuid.UUID]goreddit.ModLogEntry
+	reports      map[uuid.UUID]goreddit.Report
+	threadBans   map[threadUserKey]goreddit.ThreadBan
+	suspensions  map[uuid.UUID]goreddit.Suspension
+}
+
+type voteKey struct {
+	userID   uuid.UUID
+	targetID uuid.UUID
+}
+
+type threadUserKey struct {
+	threadID uuid.UUID
+	userID   uuid.UUID
+}
+
+func (d *db) userExists(id uuid.UUID) bool {
+	if id == uuid.Nil {
+		return true
+	}
+
+	_, ok := d.users[id]
+	return ok
+}
+
+func (d *db) username(id uuid.UUID) string {
+	return d.users[id].Username
+}
+
+func (d *db) deletePost(id uuid.UUID) {
+	for cid, c := range d.comments {
+		if c.PostID == id {
+			d.deleteComment(cid)
+		}
+	}
+	for k := range d.postVotes {
+		if k.targetID == id {
+			delete(d.postVotes, k)
+		}
+	}
+	for rid, r := range d.reports {
+		if r.PostID == id {
+			delete(d.reports, rid)
+		}
+	}
+	delete(d.posts, id)
+}
+
+func (d *db) deleteComment(id uuid.UUID) {
+	for k := range d.commentVotes {
+		if k.targetID == id {
+			delete(d.commentVotes, k)
+		}
+	}
+	for rid, r := range d.reports {
+		if r.CommentID == id {
+			delete(d.reports, rid)
+		}
+	}
+	delete(d.comments, id)
+}
+
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// sortKey orders rows in a listing. Listings run from the greatest key to
+// the smallest, comparing pinned, then time, then score, then ID, the way
+// Postgres compares row values.
+type sortKey struct {
+	pinned bool
+	time   time.Time
+	score  float64
+	id     uuid.UUID
+}
+
+func (k sortKey) less(o sortKey) bool {
+	if k.pinned != o.pinned {
+		return o.pinned
+	}
+	if !k.time.Equal(o.time) {
+		return k.time.Before(o.time)
+	}
+	if k.score != o.score {
+		return k.score < o.score
+	}
+	return bytes.Compare(k.id[:], o.id[:]) < 0
+}
+
+type entry struct {
+	key sortKey
+	id  uuid.UUID
+}
+
+// selectPage sorts entries into listing order and returns page p of them.
+// cursor returns the key of the row a page continues from; as with a
+// subquery in SQL, a cursor that no longer exists selects nothing.
+func selectPage(es []entry, p goreddit.Page, cursor func(uuid.UUID) (sortKey, bool)) []entry {
+	sort.Slice(es, func(i, j int) bool {
+		return es[j].key.less(es[i].key)
+	})
+
+	switch {
+	case p.After != uuid.Nil:
+		k, ok := cursor(p.After)
+		if !ok {
+			return []entry{}
+		}
+		es = filter(es, func(e entry) bool { return e.key.less(k) })
+	case p.Before != uuid.Nil:
+		k, ok := cursor(p.Before)
+		if !ok {
+			return []entry{}
+		}
+		es = filter(es, func(e entry) bool { return k.less(e.key) })
+		if p.Limit > 0 && len(es) > p.Limit {
+			es = es[len(es)-p.Limit:]
+		}
+		return es
+	}
+
+	if p.Limit > 0 && len(es) > p.Limit {
+		es = es[:p.Limit]
+	}
+	return es
+}
+
+func filter(es []entry, keep func(entry) bool) []entry {
+	kept := []entry{}
+	for _, e := range es {
+		if keep(e) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/blrobin2/goreddit/memory"
+	"github.com/blrobin2/goreddit/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) goreddit.Store {
+		return memory.NewStore()
+	})
+}
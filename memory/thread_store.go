@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+type ThreadStore struct {
+	*db
+}
+
+func (s *ThreadStore) Thread(ctx context.Context, id uuid.UUID) (goreddit.Thread, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.threads[id]
+	if !ok {
+		return goreddit.Thread{}, fmt.Errorf("error getting thread: %w", goreddit.ErrNotFound)
+	}
+
+	return t, nil
+}
+
+func (s *ThreadStore) Threads(ctx context.Context, p goreddit.Page) ([]goreddit.Thread, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	es := []entry{}
+	for _, t := range s.threads {
+		es = append(es, entry{key: threadKey(t), id: t.ID})
+	}
+
+	ts := []goreddit.Thread{}
+	for _, e := range selectPage(es, p, s.threadCursor) {
+		ts = append(ts, s.threads[e.id])
+	}
+
+	return ts, nil
+}
+
+func (s *ThreadStore) CreateThread(ctx context.Context, t *goreddit.Thread) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.threads[t.ID]; ok {
+		return fmt.Errorf("error creating thread: %w", goreddit.ErrConflict)
+	}
+	if !s.userExists(t.UserID) {
+		return fmt.Errorf("error creating thread: %w", goreddit.ErrNotFound)
+	}
+
+	t.CreatedAt = now()
+	t.UpdatedAt = t.CreatedAt
+	s.threads[t.ID] = *t
+	if t.UserID != uuid.Nil {
+		k := threadUserKey{threadID: t.ID, userID: t.UserID}
+		s.moderators[k] = goreddit.ThreadModerator{ThreadID: t.ID, UserID: t.UserID, CreatedAt: t.CreatedAt}
+	}
+
+	return nil
+}
+
+func (s *ThreadStore) UpdateThread(ctx context.Context, t *goreddit.Thread) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.threads[t.ID]
+	if !ok {
+		return fmt.Errorf("error updating thread: %w", goreddit.ErrNotFound)
+	}
+
+	stored.Title = t.Title
+	stored.Description = t.Description
+	stored.UpdatedAt = now()
+	s.threads[t.ID] = stored
+	*t = stored
+
+	return nil
+}
+
+func (s *ThreadStore) DeleteThread(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for pid, p := range s.posts {
+		if p.ThreadID == id {
+			s.deletePost(pid)
+		}
+	}
+	for k := range s.moderators {
+		if k.threadID == id {
+			delete(s.moderators, k)
+		}
+	}
+	for k := range s.threadBans {
+		if k.threadID == id {
+			delete(s.threadBans, k)
+		}
+	}
+	for eid, e := range s.modLog {
+		if e.ThreadID == id {
+			delete(s.modLog, eid)
+		}
+	}
+	delete(s.threads, id)
+
+	return nil
+}
+
+func threadKey(t goreddit.Thread) sortKey {
+	return sortKey{time: t.CreatedAt, id: t.ID}
+}
+
+func (s *ThreadStore) threadCursor(id uuid.UUID) (sortKey, bool) {
+	t, ok := s.threads[id]
+	return threadKey(t), ok
+}
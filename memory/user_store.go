@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+type UserStore struct {
+	*db
+}
+
+func (s *UserStore) User(ctx context.Context, id uuid.UUID) (goreddit.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return goreddit.User{}, fmt.Errorf("error getting user: %w", goreddit.ErrNotFound)
+	}
+
+	return u, nil
+}
+
+func (s *UserStore) UserByUsername(ctx context.Context, username string) (goreddit.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+
+	return goreddit.User{}, fmt.Errorf("error getting user: %w", goreddit.ErrNotFound)
+}
+
+func (s *UserStore) CreateUser(ctx context.Context, u *goreddit.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[u.ID]; ok || s.usernameTaken(u.Username, u.ID) {
+		return fmt.Errorf("error creating user: %w", goreddit.ErrConflict)
+	}
+
+	u.Role = userRole(u.Role)
+	u.PostKarma, u.CommentKarma = 0, 0
+	u.CreatedAt = now()
+	u.UpdatedAt = u.CreatedAt
+	s.users[u.ID] = *u
+
+	return nil
+}
+
+func (s *UserStore) UpdateUser(ctx context.Context, u *goreddit.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.users[u.ID]
+	if !ok {
+		return fmt.Errorf("error updating user: %w", goreddit.ErrNotFound)
+	}
+	if s.usernameTaken(u.Username, u.ID) {
+		return fmt.Errorf("error updating user: %w", goreddit.ErrConflict)
+	}
+
+	stored.Username = u.Username
+	stored.Password = u.Password
+	stored.Role = userRole(u.Role)
+	stored.UpdatedAt = now()
+	s.users[u.ID] = stored
+	*u = stored
+
+	return nil
+}
+
+func (s *UserStore) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for tid, t := range s.threads {
+		if t.UserID == id {
+			t.UserID = uuid.Nil
+			s.threads[tid] = t
+		}
+	}
+	for pid, p := range s.posts {
+		if p.UserID == id {
+			p.UserID = uuid.Nil
+			s.posts[pid] = p
+		}
+	}
+	for cid, c := range s.comments {
+		if c.UserID == id {
+			c.UserID = uuid.Nil
+			s.comments[cid] = c
+		}
+	}
+	for k := range s.postVotes {
+		if k.userID == id {
+			delete(s.postVotes, k)
+		}
+	}
+	for k := range s.commentVotes {
+		if k.userID == id {
+			delete(s.commentVotes, k)
+		}
+	}
+	for k := range s.moderators {
+		if k.userID == id {
+			delete(s.moderators, k)
+		}
+	}
+	for k, b := range s.threadBans {
+		if k.userID == id {
+			delete(s.threadBans, k)
+		} else if b.BannedBy == id {
+			b.BannedBy = uuid.Nil
+			s.threadBans[k] = b
+		}
+	}
+	delete(s.suspensions, id)
+	for uid, su := range s.suspensions {
+		if su.SuspendedBy == id {
+			su.SuspendedBy = uuid.Nil
+			s.suspensions[uid] = su
+		}
+	}
+	for rid, r := range s.reports {
+		if r.UserID == id {
+			r.UserID = uuid.Nil
+			s.reports[rid] = r
+		}
+	}
+	for eid, e := range s.modLog {
+		if e.ActorID == id {
+			e.ActorID = uuid.Nil
+		}
+		if e.TargetUserID == id {
+			e.TargetUserID = uuid.Nil
+		}
+		s.modLog[eid] = e
+	}
+	for tid, t := range s.tokens {
+		if t.UserID == id {
+			delete(s.tokens, tid)
+		}
+	}
+	delete(s.users, id)
+
+	return nil
+}
+
+func (s *UserStore) usernameTaken(username string, except uuid.UUID) bool {
+	for _, u := range s.users {
+		if u.Username == username && u.ID != except {
+			return true
+		}
+	}
+	return false
+}
+
+// userRole defaults users without a role to regular users.
+func userRole(role string) string {
+	if role == "" {
+		return goreddit.RoleUser
+	}
+
+	return role
+}
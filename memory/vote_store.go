@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+type VoteStore struct {
+	*db
+}
+
+func (s *VoteStore) PostVote(ctx context.Context, postID, userID uuid.UUID) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.postVotes[voteKey{userID: userID, targetID: postID}], nil
+}
+
+func (s *VoteStore) VotePost(ctx context.Context, postID, userID uuid.UUID, value int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.votePost(postID, userID, value, false)
+	return err
+}
+
+func (s *VoteStore) TogglePostVote(ctx context.Context, postID, userID uuid.UUID, value int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.votePost(postID, userID, value, true)
+}
+
+// votePost records value as the user's vote, or withdraws the vote if
+// toggle is set and value already is the user's vote, and returns the vote
+// they end up with.
+func (s *VoteStore) votePost(postID, userID uuid.UUID, value int, toggle bool) (int, error) {
+	p, ok := s.posts[postID]
+	if !ok || !s.userExists(userID) || userID == uuid.Nil {
+		return 0, fmt.Errorf("error voting on post: %w", goreddit.ErrNotFound)
+	}
+	if value < -1 || value > 1 {
+		return 0, fmt.Errorf("error voting on post: invalid vote value %d", value)
+	}
+
+	k := voteKey{userID: userID, targetID: postID}
+	old := s.postVotes[k]
+	if toggle && old == value {
+		value = 0
+	}
+	p.Votes, p.Upvotes, p.Downvotes = tally(p.Votes, p.Upvotes, p.Downvotes, old, value)
+	s.postVotes[k] = value
+	s.posts[postID] = p
+	if author, ok := s.users[p.UserID]; ok && author.ID != userID {
+		author.PostKarma += value - old
+		s.users[author.ID] = author
+	}
+
+	return value, nil
+}
+
+func (s *VoteStore) CommentVote(ctx context.Context, commentID, userID uuid.UUID) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.commentVotes[voteKey{userID: userID, targetID: commentID}], nil
+}
+
+func (s *VoteStore) VoteComment(ctx context.Context, commentID, userID uuid.UUID, value int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.voteComment(commentID, userID, value, false)
+	return err
+}
+
+func (s *VoteStore) ToggleCommentVote(ctx context.Context, commentID, userID uuid.UUID, value int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.voteComment(commentID, userID, value, true)
+}
+
+// voteComment records value as the user's vote, or withdraws the vote if
+// toggle is set and value already is the user's vote, and returns the vote
+// they end up with.
+func (s *VoteStore) voteComment(commentID, userID uuid.UUID, value int, toggle bool) (int, error) {
+	c, ok := s.comments[commentID]
+	if !ok || !s.userExists(userID) || userID == uuid.Nil {
+		return 0, fmt.Errorf("error voting on comment: %w", goreddit.ErrNotFound)
+	}
+	if value < -1 || value > 1 {
+		return 0, fmt.Errorf("error voting on comment: invalid vote value %d", value)
+	}
+
+	k := voteKey{userID: userID, targetID: commentID}
+	old := s.commentVotes[k]
+	if toggle && old == value {
+		value = 0
+	}
+	c.Votes, c.Upvotes, c.Downvotes = tally(c.Votes, c.Upvotes, c.Downvotes, old, value)
+	s.commentVotes[k] = value
+	s.comments[commentID] = c
+	if author, ok := s.users[c.UserID]; ok && author.ID != userID {
+		author.CommentKarma += value - old
+		s.users[author.ID] = author
+	}
+
+	return value, nil
+}
+
+// tally applies a change of vote from old to value to a target's totals.
+func tally(votes, upvotes, downvotes, old, value int) (int, int, int) {
+	votes += value - old
+	upvotes += count(value == 1) - count(old == 1)
+	downvotes += count(value == -1) - count(old == -1)
+	return votes, upvotes, downvotes
+}
+
+func count(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
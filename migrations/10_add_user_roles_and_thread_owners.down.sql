@@ -0,0 +1,3 @@
+ALTER TABLE threads DROP COLUMN user_id;
+
+ALTER TABLE users DROP COLUMN role;
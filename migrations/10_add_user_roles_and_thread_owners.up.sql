@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin'));
+
+ALTER TABLE threads ADD COLUMN user_id UUID REFERENCES users (id) ON DELETE SET NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE comments DROP COLUMN edited_at, DROP COLUMN deleted;
+
+ALTER TABLE posts DROP COLUMN edited_at;
@@ -0,0 +1,5 @@
+ALTER TABLE posts ADD COLUMN edited_at TIMESTAMPTZ;
+
+ALTER TABLE comments
+    ADD COLUMN edited_at TIMESTAMPTZ,
+    ADD COLUMN deleted BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,5 @@
+DROP INDEX comments_search_idx;
+DROP INDEX posts_search_idx;
+
+ALTER TABLE comments DROP COLUMN search;
+ALTER TABLE posts DROP COLUMN search;
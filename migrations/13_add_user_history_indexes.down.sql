@@ -0,0 +1,2 @@
+DROP INDEX comments_user_id_created_at_idx;
+DROP INDEX posts_user_id_created_at_idx;
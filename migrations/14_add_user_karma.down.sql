@@ -0,0 +1,3 @@
+ALTER TABLE users
+    DROP COLUMN comment_karma,
+    DROP COLUMN post_karma;
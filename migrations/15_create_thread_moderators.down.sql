@@ -0,0 +1,5 @@
+ALTER TABLE posts
+    DROP COLUMN pinned,
+    DROP COLUMN locked;
+
+DROP TABLE thread_moderators;
@@ -0,0 +1,17 @@
+CREATE TABLE mod_log (
+    id UUID PRIMARY KEY,
+    thread_id UUID NOT NULL REFERENCES threads (id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    target_user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    -- Removed posts and comments are deleted, so these are not foreign keys.
+    post_id UUID,
+    comment_id UUID,
+    target TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX mod_log_thread_id_created_at_idx ON mod_log (thread_id, created_at, id);
+CREATE INDEX mod_log_actor_id_idx ON mod_log (actor_id);
+CREATE INDEX mod_log_target_user_id_idx ON mod_log (target_user_id);
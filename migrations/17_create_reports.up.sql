@@ -0,0 +1,13 @@
+CREATE TABLE reports (
+    id UUID PRIMARY KEY,
+    post_id UUID NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    comment_id UUID REFERENCES comments (id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Users may report each post and comment once.
+CREATE UNIQUE INDEX reports_post_id_user_id_idx ON reports (post_id, user_id) WHERE comment_id IS NULL;
+CREATE UNIQUE INDEX reports_comment_id_user_id_idx ON reports (comment_id, user_id) WHERE comment_id IS NOT NULL;
+CREATE INDEX reports_user_id_idx ON reports (user_id);
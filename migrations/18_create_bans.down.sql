@@ -0,0 +1,3 @@
+DROP TABLE suspensions;
+
+DROP TABLE thread_bans;
@@ -0,0 +1,22 @@
+CREATE TABLE thread_bans (
+    thread_id UUID NOT NULL REFERENCES threads (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    banned_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    PRIMARY KEY (thread_id, user_id)
+);
+
+CREATE INDEX thread_bans_user_id_idx ON thread_bans (user_id);
+CREATE INDEX thread_bans_banned_by_idx ON thread_bans (banned_by);
+
+CREATE TABLE suspensions (
+    user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    suspended_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ
+);
+
+CREATE INDEX suspensions_suspended_by_idx ON suspensions (suspended_by);
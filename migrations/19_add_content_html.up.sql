@@ -0,0 +1,5 @@
+-- The HTML rendered from each post's and comment's Markdown, so pages need
+-- not render it on every view. Content written before this is rendered when
+-- it is shown.
+ALTER TABLE posts ADD COLUMN content_html TEXT NOT NULL DEFAULT '';
+ALTER TABLE comments ADD COLUMN content_html TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+UPDATE posts SET kind = 'text' WHERE kind = 'image';
+
+ALTER TABLE posts DROP COLUMN thumbnail;
+ALTER TABLE posts DROP COLUMN image;
+ALTER TABLE posts DROP CONSTRAINT posts_kind_check;
+ALTER TABLE posts ADD CONSTRAINT posts_kind_check CHECK (kind IN ('text', 'link'));
@@ -0,0 +1,6 @@
+-- Image posts keep the uploaded image and its thumbnail in the blob store;
+-- the posts table only has their keys.
+ALTER TABLE posts DROP CONSTRAINT posts_kind_check;
+ALTER TABLE posts ADD CONSTRAINT posts_kind_check CHECK (kind IN ('text', 'link', 'image'));
+ALTER TABLE posts ADD COLUMN image TEXT NOT NULL DEFAULT '';
+ALTER TABLE posts ADD COLUMN thumbnail TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- The cleared HTML is rendered again when the server starts, so there is
+-- nothing to undo.
+SELECT 1;
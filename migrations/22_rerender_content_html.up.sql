@@ -0,0 +1,5 @@
+-- HTML rendered before links were checked with character references decoded
+-- may carry script. Clearing it has posts and comments rendered again when
+-- the server next starts.
+UPDATE posts SET content_html = '';
+UPDATE comments SET content_html = '';
@@ -0,0 +1,2 @@
+ALTER TABLE comments DROP COLUMN user_id;
+ALTER TABLE posts DROP COLUMN user_id;
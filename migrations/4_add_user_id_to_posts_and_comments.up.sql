@@ -0,0 +1,2 @@
+ALTER TABLE posts ADD COLUMN user_id UUID REFERENCES users (id) ON DELETE SET NULL;
+ALTER TABLE comments ADD COLUMN user_id UUID REFERENCES users (id) ON DELETE SET NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE comments ADD COLUMN parent_id UUID REFERENCES comments (id) ON DELETE CASCADE;
+
+CREATE INDEX comments_post_id_parent_id_idx ON comments (post_id, parent_id);
@@ -0,0 +1,4 @@
+DROP INDEX posts_created_at_idx;
+
+ALTER TABLE comments DROP COLUMN upvotes, DROP COLUMN downvotes;
+ALTER TABLE posts DROP COLUMN upvotes, DROP COLUMN downvotes;
@@ -0,0 +1,39 @@
+ALTER TABLE posts
+    ADD COLUMN upvotes INT NOT NULL DEFAULT 0,
+    ADD COLUMN downvotes INT NOT NULL DEFAULT 0;
+
+ALTER TABLE comments
+    ADD COLUMN upvotes INT NOT NULL DEFAULT 0,
+    ADD COLUMN downvotes INT NOT NULL DEFAULT 0;
+
+-- Votes cast before the ledger existed only survive in the net score, so
+-- whatever the ledger does not explain is counted towards the side it leans.
+UPDATE posts SET
+    upvotes = tally.ups + GREATEST(posts.votes - (tally.ups - tally.downs), 0),
+    downvotes = tally.downs + GREATEST((tally.ups - tally.downs) - posts.votes, 0)
+FROM (
+    SELECT
+        p.id,
+        COUNT(*) FILTER (WHERE v.value = 1) AS ups,
+        COUNT(*) FILTER (WHERE v.value = -1) AS downs
+    FROM posts p
+    LEFT JOIN post_votes v ON v.post_id = p.id
+    GROUP BY p.id
+) tally
+WHERE tally.id = posts.id;
+
+UPDATE comments SET
+    upvotes = tally.ups + GREATEST(comments.votes - (tally.ups - tally.downs), 0),
+    downvotes = tally.downs + GREATEST((tally.ups - tally.downs) - comments.votes, 0)
+FROM (
+    SELECT
+        c.id,
+        COUNT(*) FILTER (WHERE v.value = 1) AS ups,
+        COUNT(*) FILTER (WHERE v.value = -1) AS downs
+    FROM comments c
+    LEFT JOIN comment_votes v ON v.comment_id = c.id
+    GROUP BY c.id
+) tally
+WHERE tally.id = comments.id;
+
+CREATE INDEX posts_created_at_idx ON posts (created_at);
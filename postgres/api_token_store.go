@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type APITokenStore struct {
+	*sqlx.DB
+}
+
+func (s *APITokenStore) APITokens(ctx context.Context, userID uuid.UUID) ([]goreddit.APIToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var ts []goreddit.APIToken
+	if err := s.SelectContext(ctx, &ts, `SELECT * FROM api_tokens WHERE user_id = $1 ORDER BY created_at DESC`, userID); err != nil {
+		return []goreddit.APIToken{}, fmt.Errorf("error getting api tokens: %w", storeError(err))
+	}
+
+	return ts, nil
+}
+
+func (s *APITokenStore) UseAPIToken(ctx context.Context, hash []byte) (goreddit.APIToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var t goreddit.APIToken
+	if err := s.GetContext(ctx, &t, `UPDATE api_tokens SET last_used_at = NOW() WHERE token_hash = $1 RETURNING *`, hash); err != nil {
+		return goreddit.APIToken{}, fmt.Errorf("error getting api token: %w", storeError(err))
+	}
+
+	return t, nil
+}
+
+func (s *APITokenStore) CreateAPIToken(ctx context.Context, t *goreddit.APIToken) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := s.GetContext(ctx, t, `INSERT INTO api_tokens (id, user_id, name, token_hash, created_at) VALUES ($1, $2, $3, $4, NOW()) RETURNING *`, t.ID, t.UserID, t.Name, t.Hash); err != nil {
+		return fmt.Errorf("error creating api token: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *APITokenStore) DeleteAPIToken(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if _, err := s.ExecContext(ctx, `DELETE FROM api_tokens WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("error deleting api token: %w", storeError(err))
+	}
+
+	return nil
+}
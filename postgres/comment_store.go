@@ -1,6 +1,9 @@
 package postgres
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/blrobin2/goreddit"
@@ -12,44 +15,253 @@ type CommentStore struct {
 	*sqlx.DB
 }
 
-func (s *CommentStore) Comment(id uuid.UUID) (goreddit.Comment, error) {
+func (s *CommentStore) Comment(ctx context.Context, id uuid.UUID) (goreddit.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
 	var c goreddit.Comment
-	if err := s.Get(&c, `SELECT * FROM comments WHERE id = $1`, id); err != nil {
-		return goreddit.Comment{}, fmt.Errorf("error getting comment: %w", err)
+	query := `
+	SELECT
+		` + commentColumns + `,
+		COALESCE(users.username, '') AS username
+	FROM comments
+	LEFT JOIN users ON users.id = comments.user_id
+	WHERE comments.id = $1
+	`
+	if err := s.GetContext(ctx, &c, query, id); err != nil {
+		return goreddit.Comment{}, fmt.Errorf("error getting comment: %w", storeError(err))
 	}
 
 	return c, nil
 }
 
-func (s *CommentStore) CommentsByPost(postID uuid.UUID) ([]goreddit.Comment, error) {
-	var ps []goreddit.Comment
-	if err := s.Select(&ps, `SELECT * FROM comments WHERE post_id = $1`, postID); err != nil {
-		return []goreddit.Comment{}, fmt.Errorf("error getting comments: %w", err)
+func (s *CommentStore) CommentsByPost(ctx context.Context, postID uuid.UUID, p goreddit.Page) ([]goreddit.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var cs []goreddit.Comment
+	cond, order, args := keyset(p, "comments", []string{"comments.votes", "comments.id"}, 2)
+	query := fmt.Sprintf(`
+	SELECT
+		%s,
+		COALESCE(users.username, '') AS username
+	FROM comments
+	LEFT JOIN users ON users.id = comments.user_id
+	WHERE post_id = $1 AND %s
+	ORDER BY %s
+	`, commentColumns, cond, order)
+	args = append([]interface{}{postID}, args...)
+	if err := s.SelectContext(ctx, &cs, query, args...); err != nil {
+		return []goreddit.Comment{}, fmt.Errorf("error getting comments: %w", storeError(err))
+	}
+	if p.Before != uuid.Nil {
+		reverse(cs)
+	}
+
+	return cs, nil
+}
+
+func (s *CommentStore) CommentsByUser(ctx context.Context, userID uuid.UUID, p goreddit.Page) ([]goreddit.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var cs []goreddit.Comment
+	cond, order, args := keyset(p, "comments", []string{"comments.created_at", "comments.id"}, 2)
+	query := fmt.Sprintf(`
+	SELECT
+		%s,
+		users.username,
+		posts.title AS post_title
+	FROM comments
+	JOIN users ON users.id = comments.user_id
+	JOIN posts ON posts.id = comments.post_id
+	WHERE comments.user_id = $1 AND %s
+	ORDER BY %s
+	`, commentColumns, cond, order)
+	args = append([]interface{}{userID}, args...)
+	if err := s.SelectContext(ctx, &cs, query, args...); err != nil {
+		return []goreddit.Comment{}, fmt.Errorf("error getting comments: %w", storeError(err))
+	}
+	if p.Before != uuid.Nil {
+		reverse(cs)
+	}
+
+	return cs, nil
+}
+
+func (s *CommentStore) CommentTree(ctx context.Context, postID uuid.UUID, p goreddit.Page) ([]goreddit.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var cs []goreddit.Comment
+	cond, order, args := keyset(p, "comments", []string{"comments.votes", "comments.id"}, 2)
+	query := fmt.Sprintf(`
+	WITH RECURSIVE roots AS (
+		SELECT comments.id
+		FROM comments
+		WHERE post_id = $1 AND parent_id IS NULL AND %s
+		ORDER BY %s
+	), ranked AS (
+		SELECT
+			comments.*,
+			ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY votes DESC, id DESC) AS sibling_rank
+		FROM comments
+		WHERE post_id = $1
+	), tree AS (
+		SELECT ranked.*, 0 AS depth, ARRAY[ranked.sibling_rank] AS path
+		FROM ranked
+		WHERE ranked.id IN (SELECT id FROM roots)
+		UNION ALL
+		SELECT ranked.*, tree.depth + 1, tree.path || ranked.sibling_rank
+		FROM ranked
+		JOIN tree ON ranked.parent_id = tree.id
+	)
+	SELECT
+		tree.id,
+		tree.post_id,
+		tree.user_id,
+		tree.parent_id,
+		tree.content,
+		tree.content_html,
+		tree.votes,
+		tree.upvotes,
+		tree.downvotes,
+		tree.created_at,
+		tree.updated_at,
+		tree.edited_at,
+		tree.deleted,
+		tree.depth,
+		COALESCE(users.username, '') AS username
+	FROM tree
+	LEFT JOIN users ON users.id = tree.user_id
+	ORDER BY tree.path
+	`, cond, order)
+	args = append([]interface{}{postID}, args...)
+	if err := s.SelectContext(ctx, &cs, query, args...); err != nil {
+		return []goreddit.Comment{}, fmt.Errorf("error getting comments: %w", storeError(err))
+	}
+
+	return cs, nil
+}
+
+func (s *CommentStore) UnrenderedComments(ctx context.Context, after uuid.UUID, limit int) ([]goreddit.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var ns []goreddit.Comment
+	query := `SELECT id, content FROM comments WHERE content <> '' AND content_html = '' AND id > $1 ORDER BY id LIMIT $2`
+	if err := s.SelectContext(ctx, &ns, query, after, limit); err != nil {
+		return []goreddit.Comment{}, fmt.Errorf("error getting comments: %w", storeError(err))
+	}
+
+	return ns, nil
+}
+
+func (s *CommentStore) SetCommentHTML(ctx context.Context, id uuid.UUID, html string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := execOne(ctx, s, `UPDATE comments SET content_html = $1 WHERE id = $2`, html, id); err != nil {
+		return fmt.Errorf("error updating comment: %w", storeError(err))
 	}
 
-	return ps, nil
+	return nil
 }
 
-func (s *CommentStore) CreateComment(c *goreddit.Comment) error {
-	if err := s.Get(c, `INSERT INTO comments VALUES ($1, $2, $3, $4) RETURNING *`, c.ID, c.PostID, c.Content, c.Votes); err != nil {
-		return fmt.Errorf("error creating comment: %w", err)
+func (s *CommentStore) CreateComment(ctx context.Context, c *goreddit.Comment) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := s.GetContext(ctx, c, `INSERT INTO comments (id, post_id, content, content_html, votes, user_id, parent_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW()) RETURNING `+commentColumns, c.ID, c.PostID, c.Content, c.ContentHTML, c.Votes, nullUUID(c.UserID), nullUUID(c.ParentID)); err != nil {
+		return fmt.Errorf("error creating comment: %w", storeError(err))
 	}
 
 	return nil
 }
 
-func (s *CommentStore) UpdateComment(c *goreddit.Comment) error {
-	if err := s.Get(c, `UPDATE comments SET post_id = $1, content = $2, votes = $3 WHERE id = $4 RETURNING *`, c.PostID, c.Content, c.Votes, c.ID); err != nil {
-		return fmt.Errorf("error updating comment: %w", err)
+func (s *CommentStore) UpdateComment(ctx context.Context, c *goreddit.Comment) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := s.GetContext(ctx, c, `UPDATE comments SET post_id = $1, content = $2, content_html = $3, edited_at = $4, updated_at = NOW() WHERE id = $5 RETURNING `+commentColumns, c.PostID, c.Content, c.ContentHTML, c.EditedAt, c.ID); err != nil {
+		return fmt.Errorf("error updating comment: %w", storeError(err))
 	}
 
 	return nil
 }
 
-func (s *CommentStore) DeleteComment(id uuid.UUID) error {
-	if _, err := s.Exec(`DELETE FROM comments WHERE id = $1`, id); err != nil {
-		return fmt.Errorf("error deleting comment: %w", err)
+// commentColumns are the columns of comments that make up a
+// goreddit.Comment. The table also has a search vector, which only queries
+// use.
+const commentColumns = `comments.id, comments.post_id, comments.user_id, comments.parent_id,
+	comments.content, comments.content_html, comments.votes, comments.upvotes, comments.downvotes,
+	comments.created_at, comments.updated_at, comments.edited_at, comments.deleted`
+
+func (s *CommentStore) DeleteComment(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := s.deleteComment(ctx, id); err != nil {
+		return fmt.Errorf("error deleting comment: %w", storeError(err))
 	}
 
 	return nil
 }
+
+func (s *CommentStore) deleteComment(ctx context.Context, id uuid.UUID) error {
+	tx, err := s.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Locking the comment keeps replies from being added while it is deleted.
+	var hasReplies bool
+	query := `
+	SELECT EXISTS (SELECT 1 FROM comments replies WHERE replies.parent_id = comments.id)
+	FROM comments
+	WHERE id = $1
+	FOR UPDATE
+	`
+	err = tx.GetContext(ctx, &hasReplies, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if hasReplies {
+		if _, err := tx.ExecContext(ctx, `UPDATE comments SET content = '', content_html = '', user_id = NULL, deleted = TRUE, updated_at = NOW() WHERE id = $1`, id); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	// Remove the comment, then any placeholders above it that were only kept
+	// for its sake.
+	for {
+		var parentID uuid.UUID
+		err := tx.GetContext(ctx, &parentID, `DELETE FROM comments WHERE id = $1 RETURNING parent_id`, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if parentID == uuid.Nil {
+			break
+		}
+
+		var orphaned bool
+		query = `SELECT deleted AND NOT EXISTS (SELECT 1 FROM comments WHERE parent_id = $1) FROM comments WHERE id = $1`
+		if err := tx.GetContext(ctx, &orphaned, query, parentID); err != nil {
+			return err
+		}
+		if !orphaned {
+			break
+		}
+		id = parentID
+	}
+
+	return tx.Commit()
+}
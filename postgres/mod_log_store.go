@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ModLogStore struct {
+	*sqlx.DB
+}
+
+func (s *ModLogStore) ModLog(ctx context.Context, threadID uuid.UUID, f goreddit.ModLogFilter, p goreddit.Page) ([]goreddit.ModLogEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	conds := []string{"mod_log.thread_id = $1"}
+	args := []interface{}{threadID}
+	if f.Action != "" {
+		args = append(args, f.Action)
+		conds = append(conds, fmt.Sprintf("mod_log.action = $%d", len(args)))
+	}
+	if f.ActorID != uuid.Nil {
+		args = append(args, f.ActorID)
+		conds = append(conds, fmt.Sprintf("mod_log.actor_id = $%d", len(args)))
+	}
+
+	cond, order, pageArgs := keyset(p, "mod_log", []string{"mod_log.created_at", "mod_log.id"}, len(args)+1)
+	conds = append(conds, cond)
+	args = append(args, pageArgs...)
+
+	var es []goreddit.ModLogEntry
+	query := fmt.Sprintf(`
+	SELECT
+		mod_log.*,
+		COALESCE(actors.username, '') AS actor_name,
+		COALESCE(targets.username, '') AS target_name
+	FROM mod_log
+	LEFT JOIN users actors ON actors.id = mod_log.actor_id
+	LEFT JOIN users targets ON targets.id = mod_log.target_user_id
+	WHERE %s
+	ORDER BY %s
+	`, strings.Join(conds, " AND "), order)
+	if err := s.SelectContext(ctx, &es, query, args...); err != nil {
+		return []goreddit.ModLogEntry{}, fmt.Errorf("error getting mod log: %w", storeError(err))
+	}
+	if p.Before != uuid.Nil {
+		reverse(es)
+	}
+
+	return es, nil
+}
+
+func (s *ModLogStore) LogModAction(ctx context.Context, e *goreddit.ModLogEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO mod_log (id, thread_id, actor_id, action, target_user_id, post_id, comment_id, target, reason, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+	RETURNING created_at
+	`
+	if err := s.GetContext(ctx, &e.CreatedAt, query, e.ID, e.ThreadID, nullUUID(e.ActorID), e.Action, nullUUID(e.TargetUserID), nullUUID(e.PostID), nullUUID(e.CommentID), e.Target, e.Reason); err != nil {
+		return fmt.Errorf("error logging mod action: %w", storeError(err))
+	}
+
+	return nil
+}
@@ -1,7 +1,9 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/blrobin2/goreddit"
 	"github.com/google/uuid"
@@ -12,72 +14,291 @@ type PostStore struct {
 	*sqlx.DB
 }
 
-func (s *PostStore) Post(id uuid.UUID) (goreddit.Post, error) {
+func (s *PostStore) Post(ctx context.Context, id uuid.UUID) (goreddit.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
 	var p goreddit.Post
-	if err := s.Get(&p, `SELECT * FROM posts WHERE id = $1`, id); err != nil {
-		return goreddit.Post{}, fmt.Errorf("error getting post: %w", err)
+	query := `
+	SELECT
+		` + postColumns + `,
+		COALESCE(users.username, '') AS username
+	FROM posts
+	LEFT JOIN users ON users.id = posts.user_id
+	WHERE posts.id = $1
+	`
+	if err := s.GetContext(ctx, &p, query, id); err != nil {
+		return goreddit.Post{}, fmt.Errorf("error getting post: %w", storeError(err))
 	}
 
 	return p, nil
 }
 
-func (s *PostStore) PostsByThead(threadID uuid.UUID) ([]goreddit.Post, error) {
+func (s *PostStore) PostsByThead(ctx context.Context, threadID uuid.UUID, l goreddit.PostListing, p goreddit.Page) ([]goreddit.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
 	var ps []goreddit.Post
-	query := `
+	cond, order, args := keyset(p, "posts", append([]string{"posts.pinned"}, postKey(l.Sort)...), 3)
+	query := fmt.Sprintf(`
 	SELECT
-		posts.*,
+		%s,
+		COALESCE(users.username, '') AS username,
 		COUNT(comments.*) AS comments_count
 	FROM posts
+	LEFT JOIN users ON users.id = posts.user_id
 	LEFT JOIN comments ON comments.post_id = posts.id
-	WHERE thread_id = $1
-	GROUP BY posts.id
-	`
-	if err := s.Select(&ps, query, threadID); err != nil {
-		return []goreddit.Post{}, fmt.Errorf("error getting posts: %w", err)
+	WHERE thread_id = $1 AND posts.created_at >= $2 AND %s
+	GROUP BY posts.id, users.username
+	ORDER BY %s
+	`, postColumns, cond, order)
+	args = append([]interface{}{threadID, listingSince(l)}, args...)
+	if err := s.SelectContext(ctx, &ps, query, args...); err != nil {
+		return []goreddit.Post{}, fmt.Errorf("error getting posts: %w", storeError(err))
+	}
+	if p.Before != uuid.Nil {
+		reverse(ps)
 	}
 
 	return ps, nil
 }
 
-func (s *PostStore) Posts() ([]goreddit.Post, error) {
+func (s *PostStore) Posts(ctx context.Context, l goreddit.PostListing, p goreddit.Page) ([]goreddit.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
 	var ps []goreddit.Post
-	query := `
+	cond, order, args := keyset(p, "posts", postKey(l.Sort), 2)
+	query := fmt.Sprintf(`
+	SELECT
+		%s,
+		threads.title AS thread_title,
+		COALESCE(users.username, '') AS username,
+		COUNT(comments.*) AS comments_count
+	FROM posts
+	JOIN threads ON posts.thread_id = threads.id
+	LEFT JOIN users ON users.id = posts.user_id
+	LEFT JOIN comments ON comments.post_id = posts.id
+	WHERE posts.created_at >= $1 AND %s
+	GROUP BY posts.id, threads.title, users.username
+	ORDER BY %s
+	`, postColumns, cond, order)
+	args = append([]interface{}{listingSince(l)}, args...)
+	if err := s.SelectContext(ctx, &ps, query, args...); err != nil {
+		return []goreddit.Post{}, fmt.Errorf("error getting posts: %w", storeError(err))
+	}
+	if p.Before != uuid.Nil {
+		reverse(ps)
+	}
+
+	return ps, nil
+}
+
+func (s *PostStore) PostsByDomain(ctx context.Context, domain string, l goreddit.PostListing, p goreddit.Page) ([]goreddit.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var ps []goreddit.Post
+	cond, order, args := keyset(p, "posts", postKey(l.Sort), 3)
+	query := fmt.Sprintf(`
 	SELECT
-		posts.*,
+		%s,
 		threads.title AS thread_title,
+		COALESCE(users.username, '') AS username,
 		COUNT(comments.*) AS comments_count
 	FROM posts
 	JOIN threads ON posts.thread_id = threads.id
+	LEFT JOIN users ON users.id = posts.user_id
 	LEFT JOIN comments ON comments.post_id = posts.id
-	GROUP BY posts.id, threads.title
+	WHERE posts.domain = $1 AND posts.domain <> '' AND posts.created_at >= $2 AND %s
+	GROUP BY posts.id, threads.title, users.username
+	ORDER BY %s
+	`, postColumns, cond, order)
+	args = append([]interface{}{domain, listingSince(l)}, args...)
+	if err := s.SelectContext(ctx, &ps, query, args...); err != nil {
+		return []goreddit.Post{}, fmt.Errorf("error getting posts: %w", storeError(err))
+	}
+	if p.Before != uuid.Nil {
+		reverse(ps)
+	}
+
+	return ps, nil
+}
+
+func (s *PostStore) PostByURL(ctx context.Context, threadID uuid.UUID, url string) (goreddit.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var p goreddit.Post
+	query := `
+	SELECT
+		` + postColumns + `,
+		COALESCE(users.username, '') AS username
+	FROM posts
+	LEFT JOIN users ON users.id = posts.user_id
+	WHERE posts.thread_id = $1 AND posts.url = $2 AND posts.url <> ''
+	ORDER BY posts.created_at DESC
+	LIMIT 1
 	`
-	if err := s.Select(&ps, query); err != nil {
-		return []goreddit.Post{}, fmt.Errorf("error getting posts: %w", err)
+	if err := s.GetContext(ctx, &p, query, threadID, url); err != nil {
+		return goreddit.Post{}, fmt.Errorf("error getting post: %w", storeError(err))
+	}
+
+	return p, nil
+}
+
+func (s *PostStore) PostsByUser(ctx context.Context, userID uuid.UUID, p goreddit.Page) ([]goreddit.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var ps []goreddit.Post
+	cond, order, args := keyset(p, "posts", []string{"posts.created_at", "posts.id"}, 2)
+	query := fmt.Sprintf(`
+	SELECT
+		%s,
+		threads.title AS thread_title,
+		users.username,
+		COUNT(comments.*) AS comments_count
+	FROM posts
+	JOIN threads ON posts.thread_id = threads.id
+	JOIN users ON users.id = posts.user_id
+	LEFT JOIN comments ON comments.post_id = posts.id
+	WHERE posts.user_id = $1 AND %s
+	GROUP BY posts.id, threads.title, users.username
+	ORDER BY %s
+	`, postColumns, cond, order)
+	args = append([]interface{}{userID}, args...)
+	if err := s.SelectContext(ctx, &ps, query, args...); err != nil {
+		return []goreddit.Post{}, fmt.Errorf("error getting posts: %w", storeError(err))
+	}
+	if p.Before != uuid.Nil {
+		reverse(ps)
 	}
 
 	return ps, nil
 }
 
-func (s *PostStore) CreatePost(p *goreddit.Post) error {
-	if err := s.Get(p, `INSERT INTO posts VALUES ($1, $2, $3, $4, $5) RETURNING *`, p.ID, p.ThreadID, p.Title, p.Content, p.Votes); err != nil {
-		return fmt.Errorf("error creating post: %w", err)
+func (s *PostStore) UnrenderedPosts(ctx context.Context, after uuid.UUID, limit int) ([]goreddit.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var ns []goreddit.Post
+	query := `SELECT id, content FROM posts WHERE content <> '' AND content_html = '' AND id > $1 ORDER BY id LIMIT $2`
+	if err := s.SelectContext(ctx, &ns, query, after, limit); err != nil {
+		return []goreddit.Post{}, fmt.Errorf("error getting posts: %w", storeError(err))
+	}
+
+	return ns, nil
+}
+
+func (s *PostStore) SetPostHTML(ctx context.Context, id uuid.UUID, html string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := execOne(ctx, s, `UPDATE posts SET content_html = $1 WHERE id = $2`, html, id); err != nil {
+		return fmt.Errorf("error updating post: %w", storeError(err))
 	}
 
 	return nil
 }
 
-func (s *PostStore) UpdatePost(p *goreddit.Post) error {
-	if err := s.Get(p, `UPDATE posts SET thread_id = $1, title = $2, content = $3, votes = $4 WHERE id = $5 RETURNING *`, p.ThreadID, p.Title, p.Content, p.Votes, p.ID); err != nil {
-		return fmt.Errorf("error updating post: %w", err)
+func (s *PostStore) CreatePost(ctx context.Context, p *goreddit.Post) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := s.GetContext(ctx, p, `INSERT INTO posts (id, thread_id, title, content, content_html, kind, url, domain, image, thumbnail, votes, user_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, COALESCE(NULLIF($6, ''), 'text'), $7, $8, $9, $10, $11, $12, NOW(), NOW()) RETURNING `+postColumns, p.ID, p.ThreadID, p.Title, p.Content, p.ContentHTML, p.Kind, p.URL, p.Domain, p.Image, p.Thumbnail, p.Votes, nullUUID(p.UserID)); err != nil {
+		return fmt.Errorf("error creating post: %w", storeError(err))
 	}
 
 	return nil
 }
 
-func (s *PostStore) DeletePost(id uuid.UUID) error {
-	if _, err := s.Exec(`DELETE FROM posts WHERE id = $1`, id); err != nil {
-		return fmt.Errorf("error deleting post: %w", err)
+func (s *PostStore) UpdatePost(ctx context.Context, p *goreddit.Post) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := s.GetContext(ctx, p, `UPDATE posts SET thread_id = $1, title = $2, content = $3, content_html = $4, kind = COALESCE(NULLIF($5, ''), 'text'), url = $6, domain = $7, image = $8, thumbnail = $9, edited_at = $10, updated_at = NOW() WHERE id = $11 RETURNING `+postColumns, p.ThreadID, p.Title, p.Content, p.ContentHTML, p.Kind, p.URL, p.Domain, p.Image, p.Thumbnail, p.EditedAt, p.ID); err != nil {
+		return fmt.Errorf("error updating post: %w", storeError(err))
 	}
 
 	return nil
 }
+
+func (s *PostStore) LockPost(ctx context.Context, id uuid.UUID, locked bool) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := execOne(ctx, s, `UPDATE posts SET locked = $1 WHERE id = $2`, locked, id); err != nil {
+		return fmt.Errorf("error locking post: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *PostStore) PinPost(ctx context.Context, id uuid.UUID, pinned bool) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := execOne(ctx, s, `UPDATE posts SET pinned = $1 WHERE id = $2`, pinned, id); err != nil {
+		return fmt.Errorf("error pinning post: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *PostStore) DeletePost(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if _, err := s.ExecContext(ctx, `DELETE FROM posts WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("error deleting post: %w", storeError(err))
+	}
+
+	return nil
+}
+
+// postColumns are the columns of posts that make up a goreddit.Post. The
+// table also has a search vector, which only queries use.
+const postColumns = `posts.id, posts.thread_id, posts.user_id, posts.title, posts.content, posts.content_html,
+	posts.kind, posts.url, posts.domain, posts.image, posts.thumbnail,
+	posts.votes, posts.upvotes, posts.downvotes, posts.created_at, posts.updated_at, posts.edited_at,
+	posts.locked, posts.pinned`
+
+// postScores maps each sort mode to the expression posts are ranked by.
+//
+// The hot score is Reddit's: the order of magnitude of the net score plus one
+// point for every 12.5 hours since the epoch, so a post needs ten times the
+// votes to outrank one submitted 12.5 hours after it.
+//
+// The controversy score is large when a post has many votes that are split
+// evenly between up and down, and zero when nobody disagrees.
+var postScores = map[goreddit.PostSort]string{
+	goreddit.SortHot: `SIGN(posts.votes) * LOG(GREATEST(ABS(posts.votes), 1)::float)
+		+ EXTRACT(EPOCH FROM posts.created_at)::float / 45000`,
+	goreddit.SortNew: `EXTRACT(EPOCH FROM posts.created_at)::float`,
+	goreddit.SortTop: `posts.votes::float`,
+	goreddit.SortControversial: `CASE WHEN posts.upvotes = 0 OR posts.downvotes = 0 THEN 0
+		ELSE POWER((posts.upvotes + posts.downvotes)::float, CASE
+			WHEN posts.upvotes > posts.downvotes THEN posts.downvotes::float / posts.upvotes
+			ELSE posts.upvotes::float / posts.downvotes
+		END) END`,
+}
+
+// postKey returns the columns a listing is ordered by.
+func postKey(sort goreddit.PostSort) []string {
+	score, ok := postScores[sort]
+	if !ok {
+		score = postScores[goreddit.SortHot]
+	}
+
+	return []string{score, "posts.id"}
+}
+
+// listingSince returns the oldest creation time included in a listing.
+func listingSince(l goreddit.PostListing) time.Time {
+	if l.Window == 0 || (l.Sort != goreddit.SortTop && l.Sort != goreddit.SortControversial) {
+		return time.Time{}
+	}
+
+	return time.Now().Add(-l.Window)
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ReportStore struct {
+	*sqlx.DB
+}
+
+func (s *ReportStore) CreateReport(ctx context.Context, r *goreddit.Report) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	query := `INSERT INTO reports (id, post_id, comment_id, user_id, reason, created_at) VALUES ($1, $2, $3, $4, $5, NOW()) RETURNING created_at`
+	if err := s.GetContext(ctx, &r.CreatedAt, query, r.ID, r.PostID, nullUUID(r.CommentID), nullUUID(r.UserID), r.Reason); err != nil {
+		return fmt.Errorf("error creating report: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *ReportStore) ReportQueue(ctx context.Context, threadID uuid.UUID) ([]goreddit.ReportedItem, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var items []goreddit.ReportedItem
+	query := `
+	SELECT
+		posts.id AS post_id,
+		comments.id AS comment_id,
+		posts.title AS post_title,
+		CASE WHEN comments.id IS NULL THEN posts.content ELSE comments.content END AS content,
+		users.id AS user_id,
+		COALESCE(users.username, '') AS username
+	FROM reports
+	JOIN posts ON posts.id = reports.post_id
+	LEFT JOIN comments ON comments.id = reports.comment_id
+	LEFT JOIN users ON users.id = CASE WHEN comments.id IS NULL THEN posts.user_id ELSE comments.user_id END
+	WHERE posts.thread_id = $1 AND NOT COALESCE(comments.deleted, FALSE)
+	GROUP BY posts.id, comments.id, users.id
+	ORDER BY COUNT(*) DESC, MAX(reports.created_at) DESC, posts.id, comments.id
+	`
+	if err := s.SelectContext(ctx, &items, query, threadID); err != nil {
+		return []goreddit.ReportedItem{}, fmt.Errorf("error getting report queue: %w", storeError(err))
+	}
+
+	var rs []goreddit.Report
+	query = `
+	SELECT reports.*
+	FROM reports
+	JOIN posts ON posts.id = reports.post_id
+	WHERE posts.thread_id = $1
+	ORDER BY reports.created_at, reports.id
+	`
+	if err := s.SelectContext(ctx, &rs, query, threadID); err != nil {
+		return []goreddit.ReportedItem{}, fmt.Errorf("error getting report queue: %w", storeError(err))
+	}
+
+	return attachReports(items, rs), nil
+}
+
+func (s *ReportStore) ClearReports(ctx context.Context, postID, commentID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var err error
+	if commentID == uuid.Nil {
+		_, err = s.ExecContext(ctx, `DELETE FROM reports WHERE post_id = $1 AND comment_id IS NULL`, postID)
+	} else {
+		_, err = s.ExecContext(ctx, `DELETE FROM reports WHERE post_id = $1 AND comment_id = $2`, postID, commentID)
+	}
+	if err != nil {
+		return fmt.Errorf("error clearing reports: %w", storeError(err))
+	}
+
+	return nil
+}
+
+// attachReports gives each queued item its reports.
+func attachReports(items []goreddit.ReportedItem, rs []goreddit.Report) []goreddit.ReportedItem {
+	type key struct{ postID, commentID uuid.UUID }
+	byItem := map[key][]goreddit.Report{}
+	for _, r := range rs {
+		k := key{r.PostID, r.CommentID}
+		byItem[k] = append(byItem[k], r)
+	}
+
+	for i := range items {
+		items[i].Reports = byItem[key{items[i].PostID, items[i].CommentID}]
+	}
+
+	return items
+}
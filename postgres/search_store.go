@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/jmoiron/sqlx"
+)
+
+type SearchStore struct {
+	*sqlx.DB
+}
+
+func (s *SearchStore) Search(ctx context.Context, q goreddit.SearchQuery, limit, offset int) ([]goreddit.SearchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if len(q.Phrases) == 0 {
+		return []goreddit.SearchResult{}, nil
+	}
+
+	// Each phrase becomes its own tsquery so its words must appear in order;
+	// a result has to match all of them.
+	args := []interface{}{q.Thread, q.Author, goreddit.MatchStart, goreddit.MatchEnd, limit, offset}
+	var phrases []string
+	for _, p := range q.Phrases {
+		args = append(args, p)
+		phrases = append(phrases, fmt.Sprintf("phraseto_tsquery('english', $%d)", len(args)))
+	}
+
+	var rs []goreddit.SearchResult
+	query := fmt.Sprintf(`
+	WITH search AS (
+		SELECT
+			%s AS query,
+			'MaxFragments=2, MinWords=10, MaxWords=30, StartSel="' || $3 || '", StopSel="' || $4 || '"' AS options
+	)
+	SELECT * FROM (
+		SELECT
+			posts.id AS post_id,
+			NULL::uuid AS comment_id,
+			posts.thread_id,
+			threads.title AS thread_title,
+			posts.title AS post_title,
+			COALESCE(users.username, '') AS username,
+			posts.created_at,
+			ts_headline('english', posts.content, search.query, search.options) AS snippet,
+			ts_rank(posts.search, search.query) AS rank
+		FROM posts
+		CROSS JOIN search
+		JOIN threads ON threads.id = posts.thread_id
+		LEFT JOIN users ON users.id = posts.user_id
+		WHERE posts.search @@ search.query
+			AND ($1 = '' OR LOWER(threads.title) = LOWER($1))
+			AND ($2 = '' OR LOWER(users.username) = LOWER($2))
+		UNION ALL
+		SELECT
+			posts.id,
+			comments.id,
+			posts.thread_id,
+			threads.title,
+			posts.title,
+			COALESCE(users.username, ''),
+			comments.created_at,
+			ts_headline('english', comments.content, search.query, search.options),
+			ts_rank(comments.search, search.query)
+		FROM comments
+		CROSS JOIN search
+		JOIN posts ON posts.id = comments.post_id
+		JOIN threads ON threads.id = posts.thread_id
+		LEFT JOIN users ON users.id = comments.user_id
+		WHERE comments.search @@ search.query
+			AND ($1 = '' OR LOWER(threads.title) = LOWER($1))
+			AND ($2 = '' OR LOWER(users.username) = LOWER($2))
+	) results
+	ORDER BY rank DESC, created_at DESC
+	LIMIT $5 OFFSET $6
+	`, strings.Join(phrases, " && "))
+	if err := s.SelectContext(ctx, &rs, query, args...); err != nil {
+		return []goreddit.SearchResult{}, fmt.Errorf("error searching: %w", storeError(err))
+	}
+
+	return rs, nil
+}
@@ -1,10 +1,20 @@
 package postgres
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
+	"time"
 
+	"github.com/alexedwards/scs/postgresstore"
+	"github.com/alexedwards/scs/v2"
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 func NewStore(dataSourceName string) (*Store, error) {
@@ -18,16 +28,132 @@ func NewStore(dataSourceName string) (*Store, error) {
 	}
 
 	return &Store{
-		ThreadStore:  &ThreadStore{DB: db},
-		PostStore:    &PostStore{DB: db},
-		CommentStore: &CommentStore{DB: db},
-		UserStore:    &UserStore{DB: db},
+		ThreadStore:    &ThreadStore{DB: db},
+		PostStore:      &PostStore{DB: db},
+		CommentStore:   &CommentStore{DB: db},
+		ModeratorStore: &ModeratorStore{DB: db},
+		ModLogStore:    &ModLogStore{DB: db},
+		ReportStore:    &ReportStore{DB: db},
+		BanStore:       &BanStore{DB: db},
+		UserStore:      &UserStore{DB: db},
+		VoteStore:      &VoteStore{DB: db},
+		APITokenStore:  &APITokenStore{DB: db},
+		SearchStore:    &SearchStore{DB: db},
 	}, nil
 }
 
+// QueryTimeout bounds each store method, on top of any deadline the
+// caller's context already carries.
+var QueryTimeout = 5 * time.Second
+
 type Store struct {
 	*ThreadStore
 	*PostStore
 	*CommentStore
+	*ModeratorStore
+	*ModLogStore
+	*ReportStore
+	*BanStore
 	*UserStore
+	*VoteStore
+	*APITokenStore
+	*SearchStore
+}
+
+// SessionStore keeps sessions in the sessions table.
+func (s *Store) SessionStore() scs.Store {
+	return postgresstore.New(s.ThreadStore.DB.DB)
+}
+
+// storeError translates missing rows and constraint violations into the
+// goreddit package's errors.
+func storeError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return goreddit.ErrNotFound
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Name() {
+		case "unique_violation":
+			return fmt.Errorf("%w: %s", goreddit.ErrConflict, pqErr.Message)
+		case "foreign_key_violation":
+			return fmt.Errorf("%w: %s", goreddit.ErrNotFound, pqErr.Message)
+		}
+	}
+
+	return err
+}
+
+// execOne runs a statement that should change exactly one row, and returns
+// sql.ErrNoRows if it changed none.
+func execOne(ctx context.Context, db sqlx.ExecerContext, query string, args ...interface{}) error {
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// nullUUID maps the zero UUID to NULL so optional foreign keys such as
+// user_id can be left empty.
+func nullUUID(id uuid.UUID) interface{} {
+	if id == uuid.Nil {
+		return nil
+	}
+
+	return id
+}
+
+// keyset builds the pieces of a query that selects page p from a listing
+// ordered by cols descending, the last of which must be the table's id. It
+// returns a condition for the WHERE clause, the ORDER BY clause and the query
+// arguments for the cursor and limit, which are numbered from arg.
+//
+// Pages before a cursor are selected in ascending order so the limit keeps
+// the rows closest to it; callers restore listing order with p.Before set.
+func keyset(p goreddit.Page, table string, cols []string, arg int) (cond string, order string, args []interface{}) {
+	key := strings.Join(cols, ", ")
+	dir, op, cursor := "DESC", "<", p.After
+	if p.Before != uuid.Nil {
+		dir, op, cursor = "ASC", ">", p.Before
+	}
+
+	cond = "TRUE"
+	if cursor != uuid.Nil {
+		cond = fmt.Sprintf("(%s) %s (SELECT %s FROM %s WHERE %s.id = $%d)", key, op, key, table, table, arg)
+		args = append(args, cursor)
+		arg++
+	}
+
+	var orders []string
+	for _, c := range cols {
+		orders = append(orders, c+" "+dir)
+	}
+	order = fmt.Sprintf("%s LIMIT $%d", strings.Join(orders, ", "), arg)
+
+	var limit interface{}
+	if p.Limit > 0 {
+		limit = p.Limit
+	}
+	args = append(args, limit)
+
+	return cond, order, args
+}
+
+// reverse reverses a slice in place. It restores listing order for pages
+// selected with keyset before a cursor.
+func reverse(slice interface{}) {
+	swap := reflect.Swapper(slice)
+	n := reflect.ValueOf(slice).Len()
+	for i := 0; i < n/2; i++ {
+		swap(i, n-1-i)
+	}
 }
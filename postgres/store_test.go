@@ -0,0 +1,33 @@
+package postgres_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/blrobin2/goreddit/postgres"
+	"github.com/blrobin2/goreddit/storetest"
+)
+
+// TestStore runs against the database in GOREDDIT_TEST_DSN, which must be
+// migrated and is emptied before every test.
+func TestStore(t *testing.T) {
+	dsn := os.Getenv("GOREDDIT_TEST_DSN")
+	if dsn == "" {
+		t.Skip("GOREDDIT_TEST_DSN is not set")
+	}
+
+	storetest.Run(t, func(t *testing.T) goreddit.Store {
+		s, err := postgres.NewStore(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.ThreadStore.Close() })
+
+		if _, err := s.ThreadStore.Exec(`TRUNCATE threads, posts, comments, users, post_votes, comment_votes, api_tokens, thread_moderators, mod_log, reports, thread_bans, suspensions`); err != nil {
+			t.Fatal(err)
+		}
+
+		return s
+	})
+}
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/blrobin2/goreddit"
@@ -12,43 +13,78 @@ type ThreadStore struct {
 	*sqlx.DB
 }
 
-func (s *ThreadStore) Thread(id uuid.UUID) (goreddit.Thread, error) {
+func (s *ThreadStore) Thread(ctx context.Context, id uuid.UUID) (goreddit.Thread, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
 	var t goreddit.Thread
-	if err := s.Get(&t, `SELECT * FROM threads WHERE id = $1`, id); err != nil {
-		return goreddit.Thread{}, fmt.Errorf("error getting thread: %w", err)
+	if err := s.GetContext(ctx, &t, `SELECT * FROM threads WHERE id = $1`, id); err != nil {
+		return goreddit.Thread{}, fmt.Errorf("error getting thread: %w", storeError(err))
 	}
 
 	return t, nil
 }
 
-func (s *ThreadStore) Threads() ([]goreddit.Thread, error) {
+func (s *ThreadStore) Threads(ctx context.Context, p goreddit.Page) ([]goreddit.Thread, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
 	var ts []goreddit.Thread
-	if err := s.Select(&ts, `SELECT * FROM threads`); err != nil {
-		return []goreddit.Thread{}, fmt.Errorf("error getting threads: %w", err)
+	cond, order, args := keyset(p, "threads", []string{"threads.created_at", "threads.id"}, 1)
+	query := fmt.Sprintf(`SELECT * FROM threads WHERE %s ORDER BY %s`, cond, order)
+	if err := s.SelectContext(ctx, &ts, query, args...); err != nil {
+		return []goreddit.Thread{}, fmt.Errorf("error getting threads: %w", storeError(err))
+	}
+	if p.Before != uuid.Nil {
+		reverse(ts)
 	}
 
 	return ts, nil
 }
 
-func (s *ThreadStore) CreateThread(t *goreddit.Thread) error {
-	if err := s.Get(t, `INSERT INTO threads VALUES ($1, $2, $3) RETURNING *`, t.ID, t.Title, t.Description); err != nil {
+func (s *ThreadStore) CreateThread(ctx context.Context, t *goreddit.Thread) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	tx, err := s.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error creating thread: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.GetContext(ctx, t, `INSERT INTO threads (id, user_id, title, description, created_at, updated_at) VALUES ($1, $2, $3, $4, NOW(), NOW()) RETURNING *`, t.ID, nullUUID(t.UserID), t.Title, t.Description); err != nil {
+		return fmt.Errorf("error creating thread: %w", storeError(err))
+	}
+	if t.UserID != uuid.Nil {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO thread_moderators (thread_id, user_id, created_at) VALUES ($1, $2, $3)`, t.ID, t.UserID, t.CreatedAt); err != nil {
+			return fmt.Errorf("error creating thread: %w", storeError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("error creating thread: %w", err)
 	}
 
 	return nil
 }
 
-func (s *ThreadStore) UpdateThread(t *goreddit.Thread) error {
-	if err := s.Get(t, `UPDATE threads SET title = $1, description = $2 WHERE id = $3 RETURNING *`, t.Title, t.Description, t.ID); err != nil {
-		return fmt.Errorf("error updating thread: %w", err)
+func (s *ThreadStore) UpdateThread(ctx context.Context, t *goreddit.Thread) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := s.GetContext(ctx, t, `UPDATE threads SET title = $1, description = $2, updated_at = NOW() WHERE id = $3 RETURNING *`, t.Title, t.Description, t.ID); err != nil {
+		return fmt.Errorf("error updating thread: %w", storeError(err))
 	}
 
 	return nil
 }
 
-func (s *ThreadStore) DeleteThread(id uuid.UUID) error {
-	if _, err := s.Exec(`DELETE FROM threads WHERE id = $1`, id); err != nil {
-		return fmt.Errorf("error deleting thread: %w", err)
+func (s *ThreadStore) DeleteThread(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if _, err := s.ExecContext(ctx, `DELETE FROM threads WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("error deleting thread: %w", storeError(err))
 	}
 
 	return nil
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/blrobin2/goreddit"
@@ -12,44 +13,68 @@ type UserStore struct {
 	*sqlx.DB
 }
 
-func (s *UserStore) User(id uuid.UUID) (goreddit.User, error) {
+func (s *UserStore) User(ctx context.Context, id uuid.UUID) (goreddit.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
 	var u goreddit.User
-	if err := s.Get(&u, `SELECT * FROM users WHERE id = $1`, id); err != nil {
-		return goreddit.User{}, fmt.Errorf("error getting user: %w", err)
+	if err := s.GetContext(ctx, &u, `SELECT * FROM users WHERE id = $1`, id); err != nil {
+		return goreddit.User{}, fmt.Errorf("error getting user: %w", storeError(err))
 	}
 
 	return u, nil
 }
 
-func (s *UserStore) UserByUsername(username string) (goreddit.User, error) {
+func (s *UserStore) UserByUsername(ctx context.Context, username string) (goreddit.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
 	var u goreddit.User
-	if err := s.Get(&u, `SELECT * FROM users WHERE username = $1`, username); err != nil {
-		return goreddit.User{}, fmt.Errorf("error getting user: %w", err)
+	if err := s.GetContext(ctx, &u, `SELECT * FROM users WHERE username = $1`, username); err != nil {
+		return goreddit.User{}, fmt.Errorf("error getting user: %w", storeError(err))
 	}
 
 	return u, nil
 }
 
-func (s *UserStore) CreateUser(u *goreddit.User) error {
-	if err := s.Get(u, `INSERT INTO users VALUES ($1, $2, $3) RETURNING *`, u.ID, u.Username, u.Password); err != nil {
-		return fmt.Errorf("error creating user: %w", err)
+func (s *UserStore) CreateUser(ctx context.Context, u *goreddit.User) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := s.GetContext(ctx, u, `INSERT INTO users (id, username, password, role, created_at, updated_at) VALUES ($1, $2, $3, $4, NOW(), NOW()) RETURNING *`, u.ID, u.Username, u.Password, userRole(u.Role)); err != nil {
+		return fmt.Errorf("error creating user: %w", storeError(err))
 	}
 
 	return nil
 }
 
-func (s *UserStore) UpdateUser(u *goreddit.User) error {
-	if err := s.Get(u, `UPDATE users SET username = $1, password = $2 WHERE id = $3 RETURNING *`, u.Username, u.Password, u.ID); err != nil {
-		return fmt.Errorf("error updating user: %w", err)
+func (s *UserStore) UpdateUser(ctx context.Context, u *goreddit.User) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := s.GetContext(ctx, u, `UPDATE users SET username = $1, password = $2, role = $3, updated_at = NOW() WHERE id = $4 RETURNING *`, u.Username, u.Password, userRole(u.Role), u.ID); err != nil {
+		return fmt.Errorf("error updating user: %w", storeError(err))
 	}
 
 	return nil
 }
 
-func (s *UserStore) DeleteUser(id uuid.UUID) error {
-	if _, err := s.Exec(`DELETE FROM users WHERE id = $1`, id); err != nil {
-		return fmt.Errorf("error deleting user: %w", err)
+func (s *UserStore) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if _, err := s.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("error deleting user: %w", storeError(err))
 	}
 
 	return nil
 }
+
+// userRole defaults users without a role to regular users.
+func userRole(role string) string {
+	if role == "" {
+		return goreddit.RoleUser
+	}
+
+	return role
+}
@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type BanStore struct {
+	*sqlx.DB
+}
+
+func (s *BanStore) ThreadBan(ctx context.Context, threadID, userID uuid.UUID) (goreddit.ThreadBan, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var b goreddit.ThreadBan
+	query := `
+	SELECT thread_bans.*, users.username
+	FROM thread_bans
+	JOIN users ON users.id = thread_bans.user_id
+	WHERE thread_id = ? AND user_id = ? AND (expires_at IS NULL OR expires_at > ?)
+	`
+	if err := s.GetContext(ctx, &b, query, threadID, userID, now()); err != nil {
+		return goreddit.ThreadBan{}, fmt.Errorf("error getting thread ban: %w", storeError(err))
+	}
+
+	return b, nil
+}
+
+func (s *BanStore) ThreadBans(ctx context.Context, threadID uuid.UUID) ([]goreddit.ThreadBan, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var bs []goreddit.ThreadBan
+	query := `
+	SELECT thread_bans.*, users.username
+	FROM thread_bans
+	JOIN users ON users.id = thread_bans.user_id
+	WHERE thread_id = ? AND (expires_at IS NULL OR expires_at > ?)
+	ORDER BY thread_bans.created_at DESC, thread_bans.user_id DESC
+	`
+	if err := s.SelectContext(ctx, &bs, query, threadID, now()); err != nil {
+		return []goreddit.ThreadBan{}, fmt.Errorf("error getting thread bans: %w", storeError(err))
+	}
+
+	return bs, nil
+}
+
+func (s *BanStore) BanUser(ctx context.Context, b *goreddit.ThreadBan) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	createdAt := now()
+	query := `
+	INSERT INTO thread_bans (thread_id, user_id, banned_by, reason, created_at, expires_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	ON CONFLICT (thread_id, user_id) DO UPDATE SET
+		banned_by = excluded.banned_by,
+		reason = excluded.reason,
+		created_at = excluded.created_at,
+		expires_at = excluded.expires_at
+	`
+	if _, err := s.ExecContext(ctx, query, b.ThreadID, b.UserID, nullUUID(b.BannedBy), b.Reason, createdAt, utc(b.ExpiresAt)); err != nil {
+		return fmt.Errorf("error banning user: %w", storeError(err))
+	}
+	b.CreatedAt = createdAt
+
+	return nil
+}
+
+func (s *BanStore) UnbanUser(ctx context.Context, threadID, userID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := execOne(ctx, s, `DELETE FROM thread_bans WHERE thread_id = ? AND user_id = ?`, threadID, userID); err != nil {
+		return fmt.Errorf("error unbanning user: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *BanStore) Suspension(ctx context.Context, userID uuid.UUID) (goreddit.Suspension, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var su goreddit.Suspension
+	if err := s.GetContext(ctx, &su, `SELECT * FROM suspensions WHERE user_id = ? AND (expires_at IS NULL OR expires_at > ?)`, userID, now()); err != nil {
+		return goreddit.Suspension{}, fmt.Errorf("error getting suspension: %w", storeError(err))
+	}
+
+	return su, nil
+}
+
+func (s *BanStore) SuspendUser(ctx context.Context, su *goreddit.Suspension) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	createdAt := now()
+	query := `
+	INSERT INTO suspensions (user_id, suspended_by, reason, created_at, expires_at)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT (user_id) DO UPDATE SET
+		suspended_by = excluded.suspended_by,
+		reason = excluded.reason,
+		created_at = excluded.created_at,
+		expires_at = excluded.expires_at
+	`
+	if _, err := s.ExecContext(ctx, query, su.UserID, nullUUID(su.SuspendedBy), su.Reason, createdAt, utc(su.ExpiresAt)); err != nil {
+		return fmt.Errorf("error suspending user: %w", storeError(err))
+	}
+	su.CreatedAt = createdAt
+
+	return nil
+}
+
+func (s *BanStore) UnsuspendUser(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := execOne(ctx, s, `DELETE FROM suspensions WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("error unsuspending user: %w", storeError(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,270 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type CommentStore struct {
+	*sqlx.DB
+}
+
+func (s *CommentStore) Comment(ctx context.Context, id uuid.UUID) (goreddit.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var c goreddit.Comment
+	query := `
+	SELECT
+		comments.*,
+		COALESCE(users.username, '') AS username
+	FROM comments
+	LEFT JOIN users ON users.id = comments.user_id
+	WHERE comments.id = ?
+	`
+	if err := s.GetContext(ctx, &c, query, id); err != nil {
+		return goreddit.Comment{}, fmt.Errorf("error getting comment: %w", storeError(err))
+	}
+
+	return c, nil
+}
+
+func (s *CommentStore) CommentsByPost(ctx context.Context, postID uuid.UUID, p goreddit.Page) ([]goreddit.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var cs []goreddit.Comment
+	cond, order, args := keyset(p, "comments", []string{"comments.votes", "comments.id"})
+	query := fmt.Sprintf(`
+	SELECT
+		comments.*,
+		COALESCE(users.username, '') AS username
+	FROM comments
+	LEFT JOIN users ON users.id = comments.user_id
+	WHERE post_id = ? AND %s
+	ORDER BY %s
+	`, cond, order)
+	args = append([]interface{}{postID}, args...)
+	if err := s.SelectContext(ctx, &cs, query, args...); err != nil {
+		return []goreddit.Comment{}, fmt.Errorf("error getting comments: %w", storeError(err))
+	}
+	if p.Before != uuid.Nil {
+		reverse(cs)
+	}
+
+	return cs, nil
+}
+
+func (s *CommentStore) CommentsByUser(ctx context.Context, userID uuid.UUID, p goreddit.Page) ([]goreddit.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var cs []goreddit.Comment
+	cond, order, args := keyset(p, "comments", []string{"comments.created_at", "comments.id"})
+	query := fmt.Sprintf(`
+	SELECT
+		comments.*,
+		users.username,
+		posts.title AS post_title
+	FROM comments
+	JOIN users ON users.id = comments.user_id
+	JOIN posts ON posts.id = comments.post_id
+	WHERE comments.user_id = ? AND %s
+	ORDER BY %s
+	`, cond, order)
+	args = append([]interface{}{userID}, args...)
+	if err := s.SelectContext(ctx, &cs, query, args...); err != nil {
+		return []goreddit.Comment{}, fmt.Errorf("error getting comments: %w", storeError(err))
+	}
+	if p.Before != uuid.Nil {
+		reverse(cs)
+	}
+
+	return cs, nil
+}
+
+func (s *CommentStore) CommentTree(ctx context.Context, postID uuid.UUID, p goreddit.Page) ([]goreddit.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var cs []goreddit.Comment
+	cond, order, args := keyset(p, "comments", []string{"comments.votes", "comments.id"})
+	// SQLite has no arrays, so the path to each comment is built from its
+	// ancestors' sibling ranks as zero-padded text, which sorts the same way.
+	query := fmt.Sprintf(`
+	WITH RECURSIVE roots AS (
+		SELECT comments.id
+		FROM comments
+		WHERE post_id = ? AND parent_id IS NULL AND %s
+		ORDER BY %s
+	), ranked AS (
+		SELECT
+			comments.*,
+			ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY votes DESC, id DESC) AS sibling_rank
+		FROM comments
+		WHERE post_id = ?
+	), tree AS (
+		SELECT ranked.*, 0 AS depth, printf('%%010d', ranked.sibling_rank) AS path
+		FROM ranked
+		WHERE ranked.id IN (SELECT id FROM roots)
+		UNION ALL
+		SELECT ranked.*, tree.depth + 1, tree.path || '.' || printf('%%010d', ranked.sibling_rank)
+		FROM ranked
+		JOIN tree ON ranked.parent_id = tree.id
+	)
+	SELECT
+		tree.id,
+		tree.post_id,
+		tree.user_id,
+		tree.parent_id,
+		tree.content,
+		tree.content_html,
+		tree.votes,
+		tree.upvotes,
+		tree.downvotes,
+		tree.created_at,
+		tree.updated_at,
+		tree.edited_at,
+		tree.deleted,
+		tree.depth,
+		COALESCE(users.username, '') AS username
+	FROM tree
+	LEFT JOIN users ON users.id = tree.user_id
+	ORDER BY tree.path
+	`, cond, order)
+	args = append([]interface{}{postID}, args...)
+	args = append(args, postID)
+	if err := s.SelectContext(ctx, &cs, query, args...); err != nil {
+		return []goreddit.Comment{}, fmt.Errorf("error getting comments: %w", storeError(err))
+	}
+
+	return cs, nil
+}
+
+func (s *CommentStore) UnrenderedComments(ctx context.Context, after uuid.UUID, limit int) ([]goreddit.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var ns []goreddit.Comment
+	query := `SELECT id, content FROM comments WHERE content <> '' AND content_html = '' AND id > ? ORDER BY id LIMIT ?`
+	if err := s.SelectContext(ctx, &ns, query, after, limit); err != nil {
+		return []goreddit.Comment{}, fmt.Errorf("error getting comments: %w", storeError(err))
+	}
+
+	return ns, nil
+}
+
+func (s *CommentStore) SetCommentHTML(ctx context.Context, id uuid.UUID, html string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := execOne(ctx, s, `UPDATE comments SET content_html = ? WHERE id = ?`, html, id); err != nil {
+		return fmt.Errorf("error updating comment: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *CommentStore) CreateComment(ctx context.Context, c *goreddit.Comment) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if _, err := s.ExecContext(ctx, `INSERT INTO comments (id, post_id, content, content_html, votes, user_id, parent_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`, c.ID, c.PostID, c.Content, c.ContentHTML, c.Votes, nullUUID(c.UserID), nullUUID(c.ParentID), now(), now()); err != nil {
+		return fmt.Errorf("error creating comment: %w", storeError(err))
+	}
+	if err := s.GetContext(ctx, c, `SELECT * FROM comments WHERE id = ?`, c.ID); err != nil {
+		return fmt.Errorf("error creating comment: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *CommentStore) UpdateComment(ctx context.Context, c *goreddit.Comment) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if _, err := s.ExecContext(ctx, `UPDATE comments SET post_id = ?, content = ?, content_html = ?, edited_at = ?, updated_at = ? WHERE id = ?`, c.PostID, c.Content, c.ContentHTML, utc(c.EditedAt), now(), c.ID); err != nil {
+		return fmt.Errorf("error updating comment: %w", storeError(err))
+	}
+	if err := s.GetContext(ctx, c, `SELECT * FROM comments WHERE id = ?`, c.ID); err != nil {
+		return fmt.Errorf("error updating comment: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *CommentStore) DeleteComment(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := s.deleteComment(ctx, id); err != nil {
+		return fmt.Errorf("error deleting comment: %w", storeError(err))
+	}
+
+	return nil
+}
+
+// deleteComment works like the Postgres version. The store has a single
+// connection, so the transaction alone keeps replies from being added
+// meanwhile.
+func (s *CommentStore) deleteComment(ctx context.Context, id uuid.UUID) error {
+	tx, err := s.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var hasReplies bool
+	query := `
+	SELECT EXISTS (SELECT 1 FROM comments replies WHERE replies.parent_id = comments.id)
+	FROM comments
+	WHERE id = ?
+	`
+	err = tx.GetContext(ctx, &hasReplies, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if hasReplies {
+		if _, err := tx.ExecContext(ctx, `UPDATE comments SET content = '', content_html = '', user_id = NULL, deleted = TRUE, updated_at = ? WHERE id = ?`, now(), id); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	// Remove the comment, then any placeholders above it that were only kept
+	// for its sake.
+	for {
+		var parentID uuid.UUID
+		err := tx.GetContext(ctx, &parentID, `DELETE FROM comments WHERE id = ? RETURNING parent_id`, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if parentID == uuid.Nil {
+			break
+		}
+
+		var orphaned bool
+		query = `SELECT deleted AND NOT EXISTS (SELECT 1 FROM comments WHERE parent_id = ?) FROM comments WHERE id = ?`
+		if err := tx.GetContext(ctx, &orphaned, query, parentID, parentID); err != nil {
+			return err
+		}
+		if !orphaned {
+			break
+		}
+		id = parentID
+	}
+
+	return tx.Commit()
+}
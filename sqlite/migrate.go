@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migrate applies the migrations the database has not seen yet. Progress is
+// kept in the same schema_migrations table the migrate tool uses, so either
+// can be used on a database.
+//
+// Migrations run with foreign keys off, so that one can rebuild a table
+// without the old table's rows cascading away when it is dropped. SQLite
+// only lets them be turned off outside a transaction, so this relies on db
+// having a single connection. Each migration must leave the foreign keys
+// intact.
+func migrate(db *sqlx.DB) (err error) {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER NOT NULL, dirty BOOLEAN NOT NULL)`); err != nil {
+		return err
+	}
+
+	var current int
+	if err := db.Get(&current, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`); err != nil {
+		return err
+	}
+
+	files, err := migrations.ReadDir("migrations")
+	if err != nil {
+		return err
+	}
+
+	versions := map[int]string{}
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".up.sql") {
+			continue
+		}
+		v, err := strconv.Atoi(strings.SplitN(f.Name(), "_", 2)[0])
+		if err != nil {
+			return fmt.Errorf("bad migration name %q", f.Name())
+		}
+		versions[v] = f.Name()
+	}
+
+	var pending []int
+	for v := range versions {
+		if v > current {
+			pending = append(pending, v)
+		}
+	}
+	sort.Ints(pending)
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if _, err := db.Exec(`PRAGMA foreign_keys = OFF`); err != nil {
+		return err
+	}
+	defer func() {
+		if _, fkErr := db.Exec(`PRAGMA foreign_keys = ON`); err == nil {
+			err = fkErr
+		}
+	}()
+
+	for _, v := range pending {
+		up, err := migrations.ReadFile("migrations/" + versions[v])
+		if err != nil {
+			return err
+		}
+
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(string(up)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error applying %s: %w", versions[v], err)
+		}
+		var broken int
+		if err := tx.Get(&broken, `SELECT COUNT(*) FROM pragma_foreign_key_check`); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if broken > 0 {
+			tx.Rollback()
+			return fmt.Errorf("error applying %s: %d rows have broken foreign keys", versions[v], broken)
+		}
+		if _, err := tx.Exec(`DELETE FROM schema_migrations`); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, dirty) VALUES (?, FALSE)`, v); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,6 @@
+DROP INDEX posts_thread_id_url_idx;
+DROP INDEX posts_domain_created_at_idx;
+
+ALTER TABLE posts DROP COLUMN domain;
+ALTER TABLE posts DROP COLUMN url;
+ALTER TABLE posts DROP COLUMN kind;
@@ -0,0 +1,8 @@
+-- Link posts share a URL instead of text. Their domain is kept apart from
+-- the URL so that posts can be listed by the site they link to.
+ALTER TABLE posts ADD COLUMN kind TEXT NOT NULL DEFAULT 'text' CHECK (kind IN ('text', 'link'));
+ALTER TABLE posts ADD COLUMN url TEXT NOT NULL DEFAULT '';
+ALTER TABLE posts ADD COLUMN domain TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX posts_domain_created_at_idx ON posts (domain, created_at) WHERE domain <> '';
+CREATE INDEX posts_thread_id_url_idx ON posts (thread_id, url) WHERE url <> '';
@@ -0,0 +1,46 @@
+UPDATE posts SET kind = 'text' WHERE kind = 'image';
+
+CREATE TABLE posts_new (
+    id TEXT PRIMARY KEY,
+    thread_id TEXT NOT NULL REFERENCES threads (id) ON DELETE CASCADE,
+    user_id TEXT REFERENCES users (id) ON DELETE SET NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    votes INTEGER NOT NULL,
+    upvotes INTEGER NOT NULL DEFAULT 0,
+    downvotes INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    edited_at TIMESTAMP,
+    locked BOOLEAN NOT NULL DEFAULT FALSE,
+    pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    content_html TEXT NOT NULL DEFAULT '',
+    kind TEXT NOT NULL DEFAULT 'text' CHECK (kind IN ('text', 'link')),
+    url TEXT NOT NULL DEFAULT '',
+    domain TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO posts_new (rowid, id, thread_id, user_id, title, content, votes, upvotes, downvotes, created_at, updated_at, edited_at, locked, pinned, content_html, kind, url, domain)
+SELECT rowid, id, thread_id, user_id, title, content, votes, upvotes, downvotes, created_at, updated_at, edited_at, locked, pinned, content_html, kind, url, domain FROM posts;
+
+DROP TABLE posts;
+ALTER TABLE posts_new RENAME TO posts;
+
+CREATE INDEX posts_thread_id_idx ON posts (thread_id);
+CREATE INDEX posts_created_at_idx ON posts (created_at);
+CREATE INDEX posts_user_id_created_at_idx ON posts (user_id, created_at);
+CREATE INDEX posts_domain_created_at_idx ON posts (domain, created_at) WHERE domain <> '';
+CREATE INDEX posts_thread_id_url_idx ON posts (thread_id, url) WHERE url <> '';
+
+CREATE TRIGGER posts_search_bu BEFORE UPDATE OF title, content ON posts BEGIN
+    DELETE FROM posts_search WHERE docid = old.rowid;
+END;
+CREATE TRIGGER posts_search_bd BEFORE DELETE ON posts BEGIN
+    DELETE FROM posts_search WHERE docid = old.rowid;
+END;
+CREATE TRIGGER posts_search_au AFTER UPDATE OF title, content ON posts BEGIN
+    INSERT INTO posts_search (docid, title, content) VALUES (new.rowid, new.title, new.content);
+END;
+CREATE TRIGGER posts_search_ai AFTER INSERT ON posts BEGIN
+    INSERT INTO posts_search (docid, title, content) VALUES (new.rowid, new.title, new.content);
+END;
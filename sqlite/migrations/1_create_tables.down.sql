@@ -0,0 +1,8 @@
+DROP TABLE sessions;
+DROP TABLE api_tokens;
+DROP TABLE comment_votes;
+DROP TABLE post_votes;
+DROP TABLE comments;
+DROP TABLE posts;
+DROP TABLE threads;
+DROP TABLE users;
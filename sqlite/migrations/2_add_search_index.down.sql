@@ -0,0 +1,11 @@
+DROP TRIGGER comments_search_ai;
+DROP TRIGGER comments_search_au;
+DROP TRIGGER comments_search_bd;
+DROP TRIGGER comments_search_bu;
+DROP TRIGGER posts_search_ai;
+DROP TRIGGER posts_search_au;
+DROP TRIGGER posts_search_bd;
+DROP TRIGGER posts_search_bu;
+
+DROP TABLE comments_search;
+DROP TABLE posts_search;
@@ -0,0 +1,37 @@
+-- The search tables index posts and comments by rowid. VACUUM may renumber
+-- rowids, so rebuild them afterwards:
+--   INSERT INTO posts_search (posts_search) VALUES ('rebuild');
+--   INSERT INTO comments_search (comments_search) VALUES ('rebuild');
+CREATE VIRTUAL TABLE posts_search USING fts4(content="posts", title, content, tokenize=porter);
+CREATE VIRTUAL TABLE comments_search USING fts4(content="comments", content, tokenize=porter);
+
+INSERT INTO posts_search (posts_search) VALUES ('rebuild');
+INSERT INTO comments_search (comments_search) VALUES ('rebuild');
+
+-- The search tables don't store the text themselves, so these keep the index
+-- in step with the rows.
+CREATE TRIGGER posts_search_bu BEFORE UPDATE OF title, content ON posts BEGIN
+    DELETE FROM posts_search WHERE docid = old.rowid;
+END;
+CREATE TRIGGER posts_search_bd BEFORE DELETE ON posts BEGIN
+    DELETE FROM posts_search WHERE docid = old.rowid;
+END;
+CREATE TRIGGER posts_search_au AFTER UPDATE OF title, content ON posts BEGIN
+    INSERT INTO posts_search (docid, title, content) VALUES (new.rowid, new.title, new.content);
+END;
+CREATE TRIGGER posts_search_ai AFTER INSERT ON posts BEGIN
+    INSERT INTO posts_search (docid, title, content) VALUES (new.rowid, new.title, new.content);
+END;
+
+CREATE TRIGGER comments_search_bu BEFORE UPDATE OF content ON comments BEGIN
+    DELETE FROM comments_search WHERE docid = old.rowid;
+END;
+CREATE TRIGGER comments_search_bd BEFORE DELETE ON comments BEGIN
+    DELETE FROM comments_search WHERE docid = old.rowid;
+END;
+CREATE TRIGGER comments_search_au AFTER UPDATE OF content ON comments BEGIN
+    INSERT INTO comments_search (docid, content) VALUES (new.rowid, new.content);
+END;
+CREATE TRIGGER comments_search_ai AFTER INSERT ON comments BEGIN
+    INSERT INTO comments_search (docid, content) VALUES (new.rowid, new.content);
+END;
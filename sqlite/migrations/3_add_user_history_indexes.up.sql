@@ -0,0 +1,2 @@
+CREATE INDEX posts_user_id_created_at_idx ON posts (user_id, created_at);
+CREATE INDEX comments_user_id_created_at_idx ON comments (user_id, created_at);
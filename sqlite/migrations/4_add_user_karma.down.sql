@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN comment_karma;
+ALTER TABLE users DROP COLUMN post_karma;
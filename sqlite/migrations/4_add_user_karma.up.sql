@@ -0,0 +1,20 @@
+ALTER TABLE users ADD COLUMN post_karma INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN comment_karma INTEGER NOT NULL DEFAULT 0;
+
+-- Karma counts the votes other people cast on a user's posts and comments.
+-- From here on votes keep it up to date; this counts the votes cast so far.
+UPDATE users SET
+    post_karma = COALESCE((
+        SELECT SUM(posts.votes) FROM posts WHERE posts.user_id = users.id
+    ), 0) - COALESCE((
+        SELECT SUM(post_votes.value) FROM post_votes
+        JOIN posts ON posts.id = post_votes.post_id
+        WHERE posts.user_id = users.id AND post_votes.user_id = users.id
+    ), 0),
+    comment_karma = COALESCE((
+        SELECT SUM(comments.votes) FROM comments WHERE comments.user_id = users.id
+    ), 0) - COALESCE((
+        SELECT SUM(comment_votes.value) FROM comment_votes
+        JOIN comments ON comments.id = comment_votes.comment_id
+        WHERE comments.user_id = users.id AND comment_votes.user_id = users.id
+    ), 0);
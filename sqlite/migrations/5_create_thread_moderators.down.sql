@@ -0,0 +1,4 @@
+ALTER TABLE posts DROP COLUMN pinned;
+ALTER TABLE posts DROP COLUMN locked;
+
+DROP TABLE thread_moderators;
@@ -0,0 +1,17 @@
+CREATE TABLE thread_moderators (
+    thread_id TEXT NOT NULL REFERENCES threads (id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (thread_id, user_id)
+);
+
+CREATE INDEX thread_moderators_user_id_idx ON thread_moderators (user_id);
+
+-- Thread creators moderate the threads they already have.
+INSERT INTO thread_moderators (thread_id, user_id, created_at)
+SELECT id, user_id, created_at FROM threads WHERE user_id IS NOT NULL;
+
+-- Moderators lock posts against new comments and votes, and pin them to the
+-- top of their thread.
+ALTER TABLE posts ADD COLUMN locked BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE posts ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1 @@
+DROP TABLE mod_log;
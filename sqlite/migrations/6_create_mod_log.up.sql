@@ -0,0 +1,17 @@
+CREATE TABLE mod_log (
+    id TEXT PRIMARY KEY,
+    thread_id TEXT NOT NULL REFERENCES threads (id) ON DELETE CASCADE,
+    actor_id TEXT REFERENCES users (id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    target_user_id TEXT REFERENCES users (id) ON DELETE SET NULL,
+    -- Removed posts and comments are deleted, so these are not foreign keys.
+    post_id TEXT,
+    comment_id TEXT,
+    target TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX mod_log_thread_id_created_at_idx ON mod_log (thread_id, created_at, id);
+CREATE INDEX mod_log_actor_id_idx ON mod_log (actor_id);
+CREATE INDEX mod_log_target_user_id_idx ON mod_log (target_user_id);
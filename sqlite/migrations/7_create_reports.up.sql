@@ -0,0 +1,13 @@
+CREATE TABLE reports (
+    id TEXT PRIMARY KEY,
+    post_id TEXT NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    comment_id TEXT REFERENCES comments (id) ON DELETE CASCADE,
+    user_id TEXT REFERENCES users (id) ON DELETE SET NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- Users may report each post and comment once.
+CREATE UNIQUE INDEX reports_post_id_user_id_idx ON reports (post_id, user_id) WHERE comment_id IS NULL;
+CREATE UNIQUE INDEX reports_comment_id_user_id_idx ON reports (comment_id, user_id) WHERE comment_id IS NOT NULL;
+CREATE INDEX reports_user_id_idx ON reports (user_id);
@@ -0,0 +1,22 @@
+CREATE TABLE thread_bans (
+    thread_id TEXT NOT NULL REFERENCES threads (id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    banned_by TEXT REFERENCES users (id) ON DELETE SET NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP,
+    PRIMARY KEY (thread_id, user_id)
+);
+
+CREATE INDEX thread_bans_user_id_idx ON thread_bans (user_id);
+CREATE INDEX thread_bans_banned_by_idx ON thread_bans (banned_by);
+
+CREATE TABLE suspensions (
+    user_id TEXT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    suspended_by TEXT REFERENCES users (id) ON DELETE SET NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP
+);
+
+CREATE INDEX suspensions_suspended_by_idx ON suspensions (suspended_by);
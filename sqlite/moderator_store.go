@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ModeratorStore struct {
+	*sqlx.DB
+}
+
+func (s *ModeratorStore) Moderators(ctx context.Context, threadID uuid.UUID) ([]goreddit.ThreadModerator, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var ms []goreddit.ThreadModerator
+	query := `
+	SELECT
+		thread_moderators.*,
+		users.username
+	FROM thread_moderators
+	JOIN users ON users.id = thread_moderators.user_id
+	WHERE thread_id = ?
+	ORDER BY thread_moderators.created_at, thread_moderators.user_id
+	`
+	if err := s.SelectContext(ctx, &ms, query, threadID); err != nil {
+		return []goreddit.ThreadModerator{}, fmt.Errorf("error getting moderators: %w", storeError(err))
+	}
+
+	return ms, nil
+}
+
+func (s *ModeratorStore) IsModerator(ctx context.Context, threadID, userID uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var ok bool
+	if err := s.GetContext(ctx, &ok, `SELECT EXISTS (SELECT 1 FROM thread_moderators WHERE thread_id = ? AND user_id = ?)`, threadID, userID); err != nil {
+		return false, fmt.Errorf("error getting moderator: %w", storeError(err))
+	}
+
+	return ok, nil
+}
+
+func (s *ModeratorStore) AddModerator(ctx context.Context, m *goreddit.ThreadModerator) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	createdAt := now()
+	if _, err := s.ExecContext(ctx, `INSERT INTO thread_moderators (thread_id, user_id, created_at) VALUES (?, ?, ?)`, m.ThreadID, m.UserID, createdAt); err != nil {
+		return fmt.Errorf("error adding moderator: %w", storeError(err))
+	}
+	m.CreatedAt = createdAt
+
+	return nil
+}
+
+func (s *ModeratorStore) RemoveModerator(ctx context.Context, threadID, userID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := execOne(ctx, s, `DELETE FROM thread_moderators WHERE thread_id = ? AND user_id = ?`, threadID, userID); err != nil {
+		return fmt.Errorf("error removing moderator: %w", storeError(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,310 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PostStore struct {
+	*sqlx.DB
+}
+
+func (s *PostStore) Post(ctx context.Context, id uuid.UUID) (goreddit.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var p goreddit.Post
+	query := `
+	SELECT
+		posts.*,
+		COALESCE(users.username, '') AS username
+	FROM posts
+	LEFT JOIN users ON users.id = posts.user_id
+	WHERE posts.id = ?
+	`
+	if err := s.GetContext(ctx, &p, query, id); err != nil {
+		return goreddit.Post{}, fmt.Errorf("error getting post: %w", storeError(err))
+	}
+
+	return p, nil
+}
+
+func (s *PostStore) PostsByThead(ctx context.Context, threadID uuid.UUID, l goreddit.PostListing, p goreddit.Page) ([]goreddit.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var ps []goreddit.Post
+	cond, order, args := keyset(p, "posts", append([]string{"posts.pinned"}, postKey(l.Sort)...))
+	query := fmt.Sprintf(`
+	SELECT
+		posts.*,
+		COALESCE(users.username, '') AS username,
+		COUNT(comments.id) AS comments_count
+	FROM posts
+	LEFT JOIN users ON users.id = posts.user_id
+	LEFT JOIN comments ON comments.post_id = posts.id
+	WHERE thread_id = ? AND posts.created_at >= ? AND %s
+	GROUP BY posts.id
+	ORDER BY %s
+	`, cond, order)
+	args = append([]interface{}{threadID, listingSince(l)}, args...)
+	if err := s.SelectContext(ctx, &ps, query, args...); err != nil {
+		return []goreddit.Post{}, fmt.Errorf("error getting posts: %w", storeError(err))
+	}
+	if p.Before != uuid.Nil {
+		reverse(ps)
+	}
+
+	return ps, nil
+}
+
+func (s *PostStore) Posts(ctx context.Context, l goreddit.PostListing, p goreddit.Page) ([]goreddit.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var ps []goreddit.Post
+	cond, order, args := keyset(p, "posts", postKey(l.Sort))
+	query := fmt.Sprintf(`
+	SELECT
+		posts.*,
+		threads.title AS thread_title,
+		COALESCE(users.username, '') AS username,
+		COUNT(comments.id) AS comments_count
+	FROM posts
+	JOIN threads ON posts.thread_id = threads.id
+	LEFT JOIN users ON users.id = posts.user_id
+	LEFT JOIN comments ON comments.post_id = posts.id
+	WHERE posts.created_at >= ? AND %s
+	GROUP BY posts.id
+	ORDER BY %s
+	`, cond, order)
+	args = append([]interface{}{listingSince(l)}, args...)
+	if err := s.SelectContext(ctx, &ps, query, args...); err != nil {
+		return []goreddit.Post{}, fmt.Errorf("error getting posts: %w", storeError(err))
+	}
+	if p.Before != uuid.Nil {
+		reverse(ps)
+	}
+
+	return ps, nil
+}
+
+func (s *PostStore) PostsByDomain(ctx context.Context, domain string, l goreddit.PostListing, p goreddit.Page) ([]goreddit.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var ps []goreddit.Post
+	cond, order, args := keyset(p, "posts", postKey(l.Sort))
+	query := fmt.Sprintf(`
+	SELECT
+		posts.*,
+		threads.title AS thread_title,
+		COALESCE(users.username, '') AS username,
+		COUNT(comments.id) AS comments_count
+	FROM posts
+	JOIN threads ON posts.thread_id = threads.id
+	LEFT JOIN users ON users.id = posts.user_id
+	LEFT JOIN comments ON comments.post_id = posts.id
+	WHERE posts.domain = ? AND posts.domain <> '' AND posts.created_at >= ? AND %s
+	GROUP BY posts.id
+	ORDER BY %s
+	`, cond, order)
+	args = append([]interface{}{domain, listingSince(l)}, args...)
+	if err := s.SelectContext(ctx, &ps, query, args...); err != nil {
+		return []goreddit.Post{}, fmt.Errorf("error getting posts: %w", storeError(err))
+	}
+	if p.Before != uuid.Nil {
+		reverse(ps)
+	}
+
+	return ps, nil
+}
+
+func (s *PostStore) PostByURL(ctx context.Context, threadID uuid.UUID, url string) (goreddit.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var p goreddit.Post
+	query := `
+	SELECT
+		posts.*,
+		COALESCE(users.username, '') AS username
+	FROM posts
+	LEFT JOIN users ON users.id = posts.user_id
+	WHERE posts.thread_id = ? AND posts.url = ? AND posts.url <> ''
+	ORDER BY posts.created_at DESC
+	LIMIT 1
+	`
+	if err := s.GetContext(ctx, &p, query, threadID, url); err != nil {
+		return goreddit.Post{}, fmt.Errorf("error getting post: %w", storeError(err))
+	}
+
+	return p, nil
+}
+
+func (s *PostStore) PostsByUser(ctx context.Context, userID uuid.UUID, p goreddit.Page) ([]goreddit.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var ps []goreddit.Post
+	cond, order, args := keyset(p, "posts", []string{"posts.created_at", "posts.id"})
+	query := fmt.Sprintf(`
+	SELECT
+		posts.*,
+		threads.title AS thread_title,
+		users.username,
+		COUNT(comments.id) AS comments_count
+	FROM posts
+	JOIN threads ON posts.thread_id = threads.id
+	JOIN users ON users.id = posts.user_id
+	LEFT JOIN comments ON comments.post_id = posts.id
+	WHERE posts.user_id = ? AND %s
+	GROUP BY posts.id
+	ORDER BY %s
+	`, cond, order)
+	args = append([]interface{}{userID}, args...)
+	if err := s.SelectContext(ctx, &ps, query, args...); err != nil {
+		return []goreddit.Post{}, fmt.Errorf("error getting posts: %w", storeError(err))
+	}
+	if p.Before != uuid.Nil {
+		reverse(ps)
+	}
+
+	return ps, nil
+}
+
+func (s *PostStore) UnrenderedPosts(ctx context.Context, after uuid.UUID, limit int) ([]goreddit.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var ns []goreddit.Post
+	query := `SELECT id, content FROM posts WHERE content <> '' AND content_html = '' AND id > ? ORDER BY id LIMIT ?`
+	if err := s.SelectContext(ctx, &ns, query, after, limit); err != nil {
+		return []goreddit.Post{}, fmt.Errorf("error getting posts: %w", storeError(err))
+	}
+
+	return ns, nil
+}
+
+func (s *PostStore) SetPostHTML(ctx context.Context, id uuid.UUID, html string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := execOne(ctx, s, `UPDATE posts SET content_html = ? WHERE id = ?`, html, id); err != nil {
+		return fmt.Errorf("error updating post: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *PostStore) CreatePost(ctx context.Context, p *goreddit.Post) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if _, err := s.ExecContext(ctx, `INSERT INTO posts (id, thread_id, title, content, content_html, kind, url, domain, image, thumbnail, votes, user_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?, COALESCE(NULLIF(?, ''), 'text'), ?, ?, ?, ?, ?, ?, ?, ?)`, p.ID, p.ThreadID, p.Title, p.Content, p.ContentHTML, p.Kind, p.URL, p.Domain, p.Image, p.Thumbnail, p.Votes, nullUUID(p.UserID), now(), now()); err != nil {
+		return fmt.Errorf("error creating post: %w", storeError(err))
+	}
+	if err := s.GetContext(ctx, p, `SELECT * FROM posts WHERE id = ?`, p.ID); err != nil {
+		return fmt.Errorf("error creating post: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *PostStore) UpdatePost(ctx context.Context, p *goreddit.Post) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if _, err := s.ExecContext(ctx, `UPDATE posts SET thread_id = ?, title = ?, content = ?, content_html = ?, kind = COALESCE(NULLIF(?, ''), 'text'), url = ?, domain = ?, image = ?, thumbnail = ?, edited_at = ?, updated_at = ? WHERE id = ?`, p.ThreadID, p.Title, p.Content, p.ContentHTML, p.Kind, p.URL, p.Domain, p.Image, p.Thumbnail, utc(p.EditedAt), now(), p.ID); err != nil {
+		return fmt.Errorf("error updating post: %w", storeError(err))
+	}
+	if err := s.GetContext(ctx, p, `SELECT * FROM posts WHERE id = ?`, p.ID); err != nil {
+		return fmt.Errorf("error updating post: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *PostStore) LockPost(ctx context.Context, id uuid.UUID, locked bool) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := execOne(ctx, s, `UPDATE posts SET locked = ? WHERE id = ?`, locked, id); err != nil {
+		return fmt.Errorf("error locking post: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *PostStore) PinPost(ctx context.Context, id uuid.UUID, pinned bool) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if err := execOne(ctx, s, `UPDATE posts SET pinned = ? WHERE id = ?`, pinned, id); err != nil {
+		return fmt.Errorf("error pinning post: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *PostStore) DeletePost(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if _, err := s.ExecContext(ctx, `DELETE FROM posts WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("error deleting post: %w", storeError(err))
+	}
+
+	return nil
+}
+
+// epoch converts a timestamp column to seconds since the Unix epoch.
+const epoch = `((julianday(posts.created_at) - 2440587.5) * 86400.0)`
+
+// postScores maps each sort mode to the expression posts are ranked by. They
+// match the Postgres store's; log10 and pow are registered with the driver.
+var postScores = map[goreddit.PostSort]string{
+	goreddit.SortHot: `(CASE WHEN posts.votes > 0 THEN 1 WHEN posts.votes < 0 THEN -1 ELSE 0 END)
+		* log10(CAST(MAX(ABS(posts.votes), 1) AS REAL)) + ` + epoch + ` / 45000`,
+	goreddit.SortNew: epoch,
+	goreddit.SortTop: `CAST(posts.votes AS REAL)`,
+	goreddit.SortControversial: `CASE WHEN posts.upvotes = 0 OR posts.downvotes = 0 THEN 0
+		ELSE pow(CAST(posts.upvotes + posts.downvotes AS REAL), CASE
+			WHEN posts.upvotes > posts.downvotes THEN CAST(posts.downvotes AS REAL) / posts.upvotes
+			ELSE CAST(posts.upvotes AS REAL) / posts.downvotes
+		END) END`,
+}
+
+// postKey returns the columns a listing is ordered by.
+func postKey(sort goreddit.PostSort) []string {
+	score, ok := postScores[sort]
+	if !ok {
+		score = postScores[goreddit.SortHot]
+	}
+
+	return []string{score, "posts.id"}
+}
+
+// listingSince returns the oldest creation time included in a listing.
+func listingSince(l goreddit.PostListing) time.Time {
+	if l.Window == 0 || (l.Sort != goreddit.SortTop && l.Sort != goreddit.SortControversial) {
+		return time.Time{}
+	}
+
+	return now().Add(-l.Window)
+}
+
+// utc converts an optional timestamp to UTC before it is stored.
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	u := t.UTC()
+	return &u
+}
@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/jmoiron/sqlx"
+)
+
+type SearchStore struct {
+	*sqlx.DB
+}
+
+func (s *SearchStore) Search(ctx context.Context, q goreddit.SearchQuery, limit, offset int) ([]goreddit.SearchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	match := matchExpression(q.Phrases)
+	if match == "" {
+		return []goreddit.SearchResult{}, nil
+	}
+
+	var rs []goreddit.SearchResult
+	query := `
+	SELECT * FROM (
+		SELECT
+			posts.id AS post_id,
+			NULL AS comment_id,
+			posts.thread_id,
+			threads.title AS thread_title,
+			posts.title AS post_title,
+			COALESCE(users.username, '') AS username,
+			posts.created_at,
+			snippet(posts_search, ?, ?, '…', 1, 30) AS snippet,
+			search_rank(matchinfo(posts_search, 'pcx'), 2.0, 1.0) AS rank
+		FROM posts_search
+		JOIN posts ON posts.rowid = posts_search.docid
+		JOIN threads ON threads.id = posts.thread_id
+		LEFT JOIN users ON users.id = posts.user_id
+		WHERE posts_search MATCH ?
+			AND (? = '' OR threads.title = ? COLLATE NOCASE)
+			AND (? = '' OR users.username = ? COLLATE NOCASE)
+		UNION ALL
+		SELECT
+			posts.id,
+			comments.id,
+			posts.thread_id,
+			threads.title,
+			posts.title,
+			COALESCE(users.username, ''),
+			comments.created_at,
+			snippet(comments_search, ?, ?, '…', 0, 30),
+			search_rank(matchinfo(comments_search, 'pcx'), 1.0)
+		FROM comments_search
+		JOIN comments ON comments.rowid = comments_search.docid
+		JOIN posts ON posts.id = comments.post_id
+		JOIN threads ON threads.id = posts.thread_id
+		LEFT JOIN users ON users.id = comments.user_id
+		WHERE comments_search MATCH ?
+			AND (? = '' OR threads.title = ? COLLATE NOCASE)
+			AND (? = '' OR users.username = ? COLLATE NOCASE)
+	)
+	ORDER BY rank DESC, created_at DESC
+	LIMIT ? OFFSET ?
+	`
+	filters := []interface{}{match, q.Thread, q.Thread, q.Author, q.Author}
+	var args []interface{}
+	for i := 0; i < 2; i++ {
+		args = append(args, goreddit.MatchStart, goreddit.MatchEnd)
+		args = append(args, filters...)
+	}
+	args = append(args, limit, offset)
+	if err := s.SelectContext(ctx, &rs, query, args...); err != nil {
+		return []goreddit.SearchResult{}, fmt.Errorf("error searching: %w", storeError(err))
+	}
+
+	return rs, nil
+}
+
+// matchExpression turns phrases into an FTS query that matches rows
+// containing all of them. Quoting every phrase keeps the query syntax out of
+// reach of user input.
+func matchExpression(phrases []string) string {
+	var quoted []string
+	for _, p := range phrases {
+		p = strings.TrimSpace(strings.ReplaceAll(p, `"`, " "))
+		if p != "" {
+			quoted = append(quoted, `"`+p+`"`)
+		}
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// searchRank scores a full-text match from matchinfo(table, 'pcx'): for every
+// phrase and column, the share of all the phrase's hits in that column that
+// fall in this row, weighted by column. Matches in rarer terms count for more.
+func searchRank(info []byte, weights ...float64) float64 {
+	// matchinfo is a sequence of unsigned integers in native byte order,
+	// which is little-endian on every platform we build for.
+	n := func(i int) float64 {
+		return float64(binary.LittleEndian.Uint32(info[4*i:]))
+	}
+	if len(info) < 8 {
+		return 0
+	}
+
+	phrases, cols := int(n(0)), int(n(1))
+	var score float64
+	for p := 0; p < phrases; p++ {
+		for c := 0; c < cols; c++ {
+			x := 2 + 3*(p*cols+c)
+			hits, total := n(x), n(x+1)
+			if total == 0 {
+				continue
+			}
+			weight := 1.0
+			if c < len(weights) {
+				weight = weights[c]
+			}
+			score += weight * hits / total
+		}
+	}
+
+	return score
+}
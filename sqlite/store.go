@@ -0,0 +1,198 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/alexedwards/scs/sqlite3store"
+	"github.com/alexedwards/scs/v2"
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+)
+
+func init() {
+	sql.Register("sqlite3_goreddit", &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			// SQLite leaves foreign keys off by default, and cascading
+			// deletes depend on them.
+			if _, err := conn.Exec(`PRAGMA foreign_keys = ON; PRAGMA busy_timeout = 5000`, nil); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("log10", math.Log10, true); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("pow", math.Pow, true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("search_rank", searchRank, true)
+		},
+	})
+}
+
+// NewStore opens the SQLite database in the given file, creating it if
+// needed, and brings its schema up to date.
+func NewStore(dataSourceName string) (*Store, error) {
+	db, err := sqlx.Open("sqlite3_goreddit", dataSourceName)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+
+	// SQLite allows one writer at a time. Sharing a single connection queues
+	// writers up in Go instead of failing them with SQLITE_BUSY, and makes
+	// transactions serializable.
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("error connecting to database: %w", err)
+	}
+
+	if err := migrate(db); err != nil {
+		return nil, fmt.Errorf("error migrating database: %w", err)
+	}
+
+	return &Store{
+		ThreadStore:    &ThreadStore{DB: db},
+		PostStore:      &PostStore{DB: db},
+		CommentStore:   &CommentStore{DB: db},
+		ModeratorStore: &ModeratorStore{DB: db},
+		ModLogStore:    &ModLogStore{DB: db},
+		ReportStore:    &ReportStore{DB: db},
+		BanStore:       &BanStore{DB: db},
+		UserStore:      &UserStore{DB: db},
+		VoteStore:      &VoteStore{DB: db},
+		APITokenStore:  &APITokenStore{DB: db},
+		SearchStore:    &SearchStore{DB: db},
+	}, nil
+}
+
+// QueryTimeout bounds each store method, on top of any deadline the
+// caller's context already carries.
+var QueryTimeout = 5 * time.Second
+
+type Store struct {
+	*ThreadStore
+	*PostStore
+	*CommentStore
+	*ModeratorStore
+	*ModLogStore
+	*ReportStore
+	*BanStore
+	*UserStore
+	*VoteStore
+	*APITokenStore
+	*SearchStore
+}
+
+// SessionStore keeps sessions in the same database file as everything else.
+func (s *Store) SessionStore() scs.Store {
+	return sqlite3store.New(s.ThreadStore.DB.DB)
+}
+
+// storeError translates missing rows and constraint violations into the
+// goreddit package's errors.
+func storeError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return goreddit.ErrNotFound
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return fmt.Errorf("%w: %s", goreddit.ErrConflict, sqliteErr.Error())
+		case sqlite3.ErrConstraintForeignKey:
+			return fmt.Errorf("%w: %s", goreddit.ErrNotFound, sqliteErr.Error())
+		}
+	}
+
+	return err
+}
+
+// execOne runs a statement that should change exactly one row, and returns
+// sql.ErrNoRows if it changed none.
+func execOne(ctx context.Context, db sqlx.ExecerContext, query string, args ...interface{}) error {
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// nullUUID maps the zero UUID to NULL so optional foreign keys such as
+// user_id can be left empty.
+func nullUUID(id uuid.UUID) interface{} {
+	if id == uuid.Nil {
+		return nil
+	}
+
+	return id
+}
+
+// now returns the current time in UTC. Timestamps are stored as text, so
+// they must all be in the same zone to compare correctly. Writes read their
+// rows back with a separate SELECT rather than RETURNING, whose columns lose
+// the declared types the driver needs to parse timestamps.
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// keyset builds the pieces of a query that selects page p from a listing
+// ordered by cols descending, the last of which must be the table's id. It
+// returns a condition for the WHERE clause, the ORDER BY clause and the query
+// arguments for the cursor and limit.
+//
+// Pages before a cursor are selected in ascending order so the limit keeps
+// the rows closest to it; callers restore listing order with p.Before set.
+func keyset(p goreddit.Page, table string, cols []string) (cond string, order string, args []interface{}) {
+	key := strings.Join(cols, ", ")
+	dir, op, cursor := "DESC", "<", p.After
+	if p.Before != uuid.Nil {
+		dir, op, cursor = "ASC", ">", p.Before
+	}
+
+	cond = "TRUE"
+	if cursor != uuid.Nil {
+		cond = fmt.Sprintf("(%s) %s (SELECT %s FROM %s WHERE %s.id = ?)", key, op, key, table, table)
+		args = append(args, cursor)
+	}
+
+	var orders []string
+	for _, c := range cols {
+		orders = append(orders, c+" "+dir)
+	}
+	order = strings.Join(orders, ", ") + " LIMIT ?"
+
+	// A negative limit means no limit in SQLite.
+	limit := -1
+	if p.Limit > 0 {
+		limit = p.Limit
+	}
+	args = append(args, limit)
+
+	return cond, order, args
+}
+
+// reverse reverses a slice in place. It restores listing order for pages
+// selected with keyset before a cursor.
+func reverse(slice interface{}) {
+	swap := reflect.Swapper(slice)
+	n := reflect.ValueOf(slice).Len()
+	for i := 0; i < n/2; i++ {
+		swap(i, n-1-i)
+	}
+}
@@ -0,0 +1,22 @@
+package sqlite_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/blrobin2/goreddit/sqlite"
+	"github.com/blrobin2/goreddit/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) goreddit.Store {
+		s, err := sqlite.NewStore(filepath.Join(t.TempDir(), "goreddit.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.ThreadStore.Close() })
+
+		return s
+	})
+}
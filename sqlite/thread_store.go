@@ -0,0 +1,98 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ThreadStore struct {
+	*sqlx.DB
+}
+
+func (s *ThreadStore) Thread(ctx context.Context, id uuid.UUID) (goreddit.Thread, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var t goreddit.Thread
+	if err := s.GetContext(ctx, &t, `SELECT * FROM threads WHERE id = ?`, id); err != nil {
+		return goreddit.Thread{}, fmt.Errorf("error getting thread: %w", storeError(err))
+	}
+
+	return t, nil
+}
+
+func (s *ThreadStore) Threads(ctx context.Context, p goreddit.Page) ([]goreddit.Thread, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var ts []goreddit.Thread
+	cond, order, args := keyset(p, "threads", []string{"threads.created_at", "threads.id"})
+	query := fmt.Sprintf(`SELECT * FROM threads WHERE %s ORDER BY %s`, cond, order)
+	if err := s.SelectContext(ctx, &ts, query, args...); err != nil {
+		return []goreddit.Thread{}, fmt.Errorf("error getting threads: %w", storeError(err))
+	}
+	if p.Before != uuid.Nil {
+		reverse(ts)
+	}
+
+	return ts, nil
+}
+
+func (s *ThreadStore) CreateThread(ctx context.Context, t *goreddit.Thread) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	tx, err := s.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error creating thread: %w", err)
+	}
+	defer tx.Rollback()
+
+	createdAt := now()
+	if _, err := tx.ExecContext(ctx, `INSERT INTO threads (id, user_id, title, description, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`, t.ID, nullUUID(t.UserID), t.Title, t.Description, createdAt, createdAt); err != nil {
+		return fmt.Errorf("error creating thread: %w", storeError(err))
+	}
+	if t.UserID != uuid.Nil {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO thread_moderators (thread_id, user_id, created_at) VALUES (?, ?, ?)`, t.ID, t.UserID, createdAt); err != nil {
+			return fmt.Errorf("error creating thread: %w", storeError(err))
+		}
+	}
+	if err := tx.GetContext(ctx, t, `SELECT * FROM threads WHERE id = ?`, t.ID); err != nil {
+		return fmt.Errorf("error creating thread: %w", storeError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error creating thread: %w", err)
+	}
+
+	return nil
+}
+
+func (s *ThreadStore) UpdateThread(ctx context.Context, t *goreddit.Thread) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if _, err := s.ExecContext(ctx, `UPDATE threads SET title = ?, description = ?, updated_at = ? WHERE id = ?`, t.Title, t.Description, now(), t.ID); err != nil {
+		return fmt.Errorf("error updating thread: %w", storeError(err))
+	}
+	if err := s.GetContext(ctx, t, `SELECT * FROM threads WHERE id = ?`, t.ID); err != nil {
+		return fmt.Errorf("error updating thread: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *ThreadStore) DeleteThread(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if _, err := s.ExecContext(ctx, `DELETE FROM threads WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("error deleting thread: %w", storeError(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type UserStore struct {
+	*sqlx.DB
+}
+
+func (s *UserStore) User(ctx context.Context, id uuid.UUID) (goreddit.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var u goreddit.User
+	if err := s.GetContext(ctx, &u, `SELECT * FROM users WHERE id = ?`, id); err != nil {
+		return goreddit.User{}, fmt.Errorf("error getting user: %w", storeError(err))
+	}
+
+	return u, nil
+}
+
+func (s *UserStore) UserByUsername(ctx context.Context, username string) (goreddit.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var u goreddit.User
+	if err := s.GetContext(ctx, &u, `SELECT * FROM users WHERE username = ?`, username); err != nil {
+		return goreddit.User{}, fmt.Errorf("error getting user: %w", storeError(err))
+	}
+
+	return u, nil
+}
+
+func (s *UserStore) CreateUser(ctx context.Context, u *goreddit.User) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if _, err := s.ExecContext(ctx, `INSERT INTO users (id, username, password, role, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`, u.ID, u.Username, u.Password, userRole(u.Role), now(), now()); err != nil {
+		return fmt.Errorf("error creating user: %w", storeError(err))
+	}
+	if err := s.GetContext(ctx, u, `SELECT * FROM users WHERE id = ?`, u.ID); err != nil {
+		return fmt.Errorf("error creating user: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *UserStore) UpdateUser(ctx context.Context, u *goreddit.User) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if _, err := s.ExecContext(ctx, `UPDATE users SET username = ?, password = ?, role = ?, updated_at = ? WHERE id = ?`, u.Username, u.Password, userRole(u.Role), now(), u.ID); err != nil {
+		return fmt.Errorf("error updating user: %w", storeError(err))
+	}
+	if err := s.GetContext(ctx, u, `SELECT * FROM users WHERE id = ?`, u.ID); err != nil {
+		return fmt.Errorf("error updating user: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *UserStore) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if _, err := s.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("error deleting user: %w", storeError(err))
+	}
+
+	return nil
+}
+
+// userRole defaults users without a role to regular users.
+func userRole(role string) string {
+	if role == "" {
+		return goreddit.RoleUser
+	}
+
+	return role
+}
@@ -0,0 +1,145 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type VoteStore struct {
+	*sqlx.DB
+}
+
+func (s *VoteStore) PostVote(ctx context.Context, postID, userID uuid.UUID) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var v int
+	err := s.GetContext(ctx, &v, `SELECT value FROM post_votes WHERE post_id = ? AND user_id = ?`, postID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error getting post vote: %w", storeError(err))
+	}
+
+	return v, nil
+}
+
+func (s *VoteStore) VotePost(ctx context.Context, postID, userID uuid.UUID, value int) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if _, err := s.vote(ctx, "post_votes", "post_id", "posts", "post_karma", postID, userID, value, false); err != nil {
+		return fmt.Errorf("error voting on post: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *VoteStore) TogglePostVote(ctx context.Context, postID, userID uuid.UUID, value int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	v, err := s.vote(ctx, "post_votes", "post_id", "posts", "post_karma", postID, userID, value, true)
+	if err != nil {
+		return 0, fmt.Errorf("error voting on post: %w", storeError(err))
+	}
+
+	return v, nil
+}
+
+func (s *VoteStore) CommentVote(ctx context.Context, commentID, userID uuid.UUID) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	var v int
+	err := s.GetContext(ctx, &v, `SELECT value FROM comment_votes WHERE comment_id = ? AND user_id = ?`, commentID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error getting comment vote: %w", storeError(err))
+	}
+
+	return v, nil
+}
+
+func (s *VoteStore) VoteComment(ctx context.Context, commentID, userID uuid.UUID, value int) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	if _, err := s.vote(ctx, "comment_votes", "comment_id", "comments", "comment_karma", commentID, userID, value, false); err != nil {
+		return fmt.Errorf("error voting on comment: %w", storeError(err))
+	}
+
+	return nil
+}
+
+func (s *VoteStore) ToggleCommentVote(ctx context.Context, commentID, userID uuid.UUID, value int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	v, err := s.vote(ctx, "comment_votes", "comment_id", "comments", "comment_karma", commentID, userID, value, true)
+	if err != nil {
+		return 0, fmt.Errorf("error voting on comment: %w", storeError(err))
+	}
+
+	return v, nil
+}
+
+// vote records value as the user's vote on the target row, or withdraws the
+// vote if toggle is set and value already is the user's vote, and returns the
+// vote the user ends up with. It applies the
+// difference to the target's cached vote tallies and, unless the user wrote
+// it, to its author's karma. Transactions on the store's single connection
+// run one at a time, so the totals never drift.
+func (s *VoteStore) vote(ctx context.Context, ledger, column, target, karma string, targetID, userID uuid.UUID, value int, toggle bool) (int, error) {
+	if value < -1 || value > 1 {
+		return 0, fmt.Errorf("invalid vote value %d", value)
+	}
+
+	tx, err := s.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var old int
+	err = tx.GetContext(ctx, &old, fmt.Sprintf(`SELECT value FROM %s WHERE user_id = ? AND %s = ?`, ledger, column), userID, targetID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	if toggle && old == value {
+		value = 0
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, %s, value) VALUES (?, ?, ?) ON CONFLICT (user_id, %s) DO UPDATE SET value = excluded.value`, ledger, column, column)
+	if _, err := tx.ExecContext(ctx, query, userID, targetID, value); err != nil {
+		return 0, err
+	}
+
+	query = fmt.Sprintf(`UPDATE %s SET votes = votes + ?, upvotes = upvotes + ?, downvotes = downvotes + ? WHERE id = ?`, target)
+	if _, err := tx.ExecContext(ctx, query, value-old, count(value == 1)-count(old == 1), count(value == -1)-count(old == -1), targetID); err != nil {
+		return 0, err
+	}
+
+	query = fmt.Sprintf(`UPDATE users SET %s = %s + ? WHERE id = (SELECT user_id FROM %s WHERE id = ?) AND id <> ?`, karma, karma, target)
+	if _, err := tx.ExecContext(ctx, query, value-old, targetID, userID); err != nil {
+		return 0, err
+	}
+
+	return value, tx.Commit()
+}
+
+func count(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
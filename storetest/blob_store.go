@@ -0,0 +1,77 @@
+package storetest
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/blrobin2/goreddit"
+)
+
+// RunBlobStore checks that blob stores returned by newStore behave the way
+// the rest of the application expects. Each test gets its own store, which
+// must be empty.
+func RunBlobStore(t *testing.T, newStore func(t *testing.T) goreddit.BlobStore) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s goreddit.BlobStore)
+	}{
+		{"Blobs", testBlobs},
+		{"BlobKeys", testBlobKeys},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStore(t))
+		})
+	}
+}
+
+func testBlobs(t *testing.T, s goreddit.BlobStore) {
+	if _, err := s.Blob(ctx, "images/missing.png"); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Blob of a missing key: err = %v, want ErrNotFound", err)
+	}
+
+	if err := s.PutBlob(ctx, "images/a.png", strings.NewReader("first")); err != nil {
+		t.Fatalf("PutBlob: %v", err)
+	}
+	if err := s.PutBlob(ctx, "images/a.png", strings.NewReader("second")); err != nil {
+		t.Fatalf("PutBlob over an existing blob: %v", err)
+	}
+
+	b, err := s.Blob(ctx, "images/a.png")
+	if err != nil {
+		t.Fatalf("Blob: %v", err)
+	}
+	data, err := io.ReadAll(b)
+	b.Close()
+	if err != nil {
+		t.Fatalf("reading blob: %v", err)
+	}
+	if string(data) != "second" || b.Size != int64(len("second")) || b.ModTime.IsZero() {
+		t.Errorf("Blob = %q, size %d, modified %v; want %q", data, b.Size, b.ModTime, "second")
+	}
+
+	if err := s.DeleteBlob(ctx, "images/a.png"); err != nil {
+		t.Fatalf("DeleteBlob: %v", err)
+	}
+	if _, err := s.Blob(ctx, "images/a.png"); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Blob after delete: err = %v, want ErrNotFound", err)
+	}
+	if err := s.DeleteBlob(ctx, "images/a.png"); err != nil {
+		t.Errorf("DeleteBlob of a missing key: %v", err)
+	}
+}
+
+func testBlobKeys(t *testing.T, s goreddit.BlobStore) {
+	for _, key := range []string{"", "../escape.png", "/images/a.png", "images/../../a.png", "images//a.png"} {
+		if err := s.PutBlob(ctx, key, strings.NewReader("data")); err == nil {
+			t.Errorf("PutBlob accepted the key %q", key)
+		}
+		if _, err := s.Blob(ctx, key); !errors.Is(err, goreddit.ErrNotFound) {
+			t.Errorf("Blob(%q): err = %v, want ErrNotFound", key, err)
+		}
+	}
+}
@@ -0,0 +1,1579 @@
+// Package storetest checks that an implementation of goreddit.Store
+// behaves the way the rest of the application expects.
+package storetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+var ctx = context.Background()
+
+// Run runs the conformance tests against stores returned by newStore. Each
+// test gets its own store, which must be empty.
+func Run(t *testing.T, newStore func(t *testing.T) goreddit.Store) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s goreddit.Store)
+	}{
+		{"Threads", testThreads},
+		{"ThreadPages", testThreadPages},
+		{"Posts", testPosts},
+		{"PostListings", testPostListings},
+		{"LinkPosts", testLinkPosts},
+		{"ImagePosts", testImagePosts},
+		{"Unrendered", testUnrendered},
+		{"Comments", testComments},
+		{"CommentTree", testCommentTree},
+		{"DeleteComment", testDeleteComment},
+		{"Moderators", testModerators},
+		{"LockAndPin", testLockAndPin},
+		{"ModLog", testModLog},
+		{"Reports", testReports},
+		{"Bans", testBans},
+		{"Suspensions", testSuspensions},
+		{"Users", testUsers},
+		{"UserHistory", testUserHistory},
+		{"DeleteUser", testDeleteUser},
+		{"Votes", testVotes},
+		{"ToggleVotes", testToggleVotes},
+		{"Karma", testKarma},
+		{"APITokens", testAPITokens},
+		{"Search", testSearch},
+		{"CascadingDeletes", testCascadingDeletes},
+		{"Errors", testErrors},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStore(t))
+		})
+	}
+}
+
+func testThreads(t *testing.T, s goreddit.Store) {
+	u := createUser(t, s, "alice")
+	th := goreddit.Thread{ID: uuid.New(), UserID: u.ID, Title: "Go", Description: "All things Go"}
+	if err := s.CreateThread(ctx, &th); err != nil {
+		t.Fatalf("CreateThread: %v", err)
+	}
+	if th.CreatedAt.IsZero() || th.UpdatedAt.IsZero() {
+		t.Errorf("CreateThread did not set timestamps: %+v", th)
+	}
+
+	got, err := s.Thread(ctx, th.ID)
+	if err != nil {
+		t.Fatalf("Thread: %v", err)
+	}
+	if got.Title != "Go" || got.Description != "All things Go" || got.UserID != u.ID {
+		t.Errorf("Thread = %+v, want %+v", got, th)
+	}
+
+	th.Title = "Golang"
+	if err := s.UpdateThread(ctx, &th); err != nil {
+		t.Fatalf("UpdateThread: %v", err)
+	}
+	if got, _ := s.Thread(ctx, th.ID); got.Title != "Golang" {
+		t.Errorf("Thread title after update = %q, want %q", got.Title, "Golang")
+	}
+
+	if err := s.DeleteThread(ctx, th.ID); err != nil {
+		t.Fatalf("DeleteThread: %v", err)
+	}
+	if _, err := s.Thread(ctx, th.ID); err == nil {
+		t.Error("Thread returned a deleted thread")
+	}
+	if _, err := s.Thread(ctx, uuid.New()); err == nil {
+		t.Error("Thread returned no error for a missing thread")
+	}
+}
+
+func testThreadPages(t *testing.T, s goreddit.Store) {
+	var ids []uuid.UUID
+	for i := 0; i < 5; i++ {
+		th := createThread(t, s, uuid.Nil)
+		ids = append([]uuid.UUID{th.ID}, ids...)
+		// Threads are listed newest first, so they need distinct times.
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	first, err := s.Threads(ctx, goreddit.Page{Limit: 2})
+	if err != nil {
+		t.Fatalf("Threads: %v", err)
+	}
+	assertIDs(t, "first page", threadIDs(first), ids[:2])
+
+	next, err := s.Threads(ctx, goreddit.Page{After: ids[1], Limit: 2})
+	if err != nil {
+		t.Fatalf("Threads: %v", err)
+	}
+	assertIDs(t, "page after", threadIDs(next), ids[2:4])
+
+	prev, err := s.Threads(ctx, goreddit.Page{Before: ids[4], Limit: 2})
+	if err != nil {
+		t.Fatalf("Threads: %v", err)
+	}
+	assertIDs(t, "page before", threadIDs(prev), ids[2:4])
+
+	all, err := s.Threads(ctx, goreddit.Page{})
+	if err != nil {
+		t.Fatalf("Threads: %v", err)
+	}
+	assertIDs(t, "all threads", threadIDs(all), ids)
+}
+
+func testPosts(t *testing.T, s goreddit.Store) {
+	u := createUser(t, s, "alice")
+	th := createThread(t, s, u.ID)
+
+	p := goreddit.Post{ID: uuid.New(), ThreadID: th.ID, UserID: u.ID, Title: "Hello", Content: "*World*", ContentHTML: "<p><em>World</em></p>"}
+	if err := s.CreatePost(ctx, &p); err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	if p.CreatedAt.IsZero() {
+		t.Error("CreatePost did not set created_at")
+	}
+	if err := s.CreatePost(ctx, &goreddit.Post{ID: uuid.New(), ThreadID: uuid.New(), Title: "Orphan"}); err == nil {
+		t.Error("CreatePost accepted a post in a missing thread")
+	}
+
+	got, err := s.Post(ctx, p.ID)
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if got.Title != "Hello" || got.Content != "*World*" || got.ContentHTML != "<p><em>World</em></p>" || got.Username != "alice" {
+		t.Errorf("Post = %+v", got)
+	}
+	if got.Kind != goreddit.PostText || got.IsLink() {
+		t.Errorf("Post kind = %q, want %q", got.Kind, goreddit.PostText)
+	}
+
+	createComment(t, s, p.ID, uuid.Nil, u.ID)
+	createComment(t, s, p.ID, uuid.Nil, u.ID)
+
+	ps, err := s.Posts(ctx, goreddit.PostListing{Sort: goreddit.SortNew}, goreddit.Page{})
+	if err != nil {
+		t.Fatalf("Posts: %v", err)
+	}
+	if len(ps) != 1 {
+		t.Fatalf("Posts returned %d posts, want 1", len(ps))
+	}
+	if ps[0].CommentsCount != 2 || ps[0].ThreadTitle != th.Title || ps[0].Username != "alice" || ps[0].ContentHTML != p.ContentHTML {
+		t.Errorf("Posts()[0] = %+v, want 2 comments in %q by alice", ps[0], th.Title)
+	}
+
+	edited := time.Now()
+	p.Title = "Hello again"
+	p.ContentHTML = "<p><strong>World</strong></p>"
+	p.EditedAt = &edited
+	if err := s.UpdatePost(ctx, &p); err != nil {
+		t.Fatalf("UpdatePost: %v", err)
+	}
+	got, _ = s.Post(ctx, p.ID)
+	if got.Title != "Hello again" || got.ContentHTML != "<p><strong>World</strong></p>" || got.EditedAt == nil {
+		t.Errorf("Post after update = %+v", got)
+	}
+
+	if err := s.DeletePost(ctx, p.ID); err != nil {
+		t.Fatalf("DeletePost: %v", err)
+	}
+	if _, err := s.Post(ctx, p.ID); err == nil {
+		t.Error("Post returned a deleted post")
+	}
+}
+
+func testPostListings(t *testing.T, s goreddit.Store) {
+	voters := []goreddit.User{createUser(t, s, "a"), createUser(t, s, "b"), createUser(t, s, "c")}
+	th := createThread(t, s, uuid.Nil)
+	other := createThread(t, s, uuid.Nil)
+
+	// old is the oldest post and the most upvoted, mixed the most evenly
+	// divided and recent the newest.
+	old := createPost(t, s, th.ID, uuid.Nil)
+	time.Sleep(2 * time.Millisecond)
+	mixed := createPost(t, s, th.ID, uuid.Nil)
+	time.Sleep(2 * time.Millisecond)
+	recent := createPost(t, s, other.ID, uuid.Nil)
+
+	for _, v := range voters {
+		vote(t, s.VotePost, old.ID, v.ID, 1)
+	}
+	vote(t, s.VotePost, mixed.ID, voters[0].ID, 1)
+	vote(t, s.VotePost, mixed.ID, voters[1].ID, -1)
+
+	listings := []struct {
+		listing goreddit.PostListing
+		want    []uuid.UUID
+	}{
+		{goreddit.PostListing{Sort: goreddit.SortNew}, []uuid.UUID{recent.ID, mixed.ID, old.ID}},
+		{goreddit.PostListing{Sort: goreddit.SortTop}, []uuid.UUID{old.ID, maxID(mixed.ID, recent.ID), minID(mixed.ID, recent.ID)}},
+		{goreddit.PostListing{Sort: goreddit.SortControversial}, []uuid.UUID{mixed.ID, maxID(old.ID, recent.ID), minID(old.ID, recent.ID)}},
+		{goreddit.PostListing{Sort: goreddit.SortHot}, []uuid.UUID{old.ID, recent.ID, mixed.ID}},
+	}
+	for _, l := range listings {
+		ps, err := s.Posts(ctx, l.listing, goreddit.Page{})
+		if err != nil {
+			t.Fatalf("Posts(%s): %v", l.listing.Sort, err)
+		}
+		assertIDs(t, string(l.listing.Sort), postIDs(ps), l.want)
+
+		page, err := s.Posts(ctx, l.listing, goreddit.Page{After: l.want[0], Limit: 1})
+		if err != nil {
+			t.Fatalf("Posts(%s): %v", l.listing.Sort, err)
+		}
+		assertIDs(t, string(l.listing.Sort)+" after first", postIDs(page), l.want[1:2])
+	}
+
+	ps, err := s.PostsByThead(ctx, th.ID, goreddit.PostListing{Sort: goreddit.SortNew}, goreddit.Page{})
+	if err != nil {
+		t.Fatalf("PostsByThead: %v", err)
+	}
+	assertIDs(t, "thread posts", postIDs(ps), []uuid.UUID{mixed.ID, old.ID})
+
+	ps, err = s.Posts(ctx, goreddit.PostListing{Sort: goreddit.SortTop, Window: time.Nanosecond}, goreddit.Page{})
+	if err != nil {
+		t.Fatalf("Posts: %v", err)
+	}
+	if len(ps) != 0 {
+		t.Errorf("Posts within a window of 1ns returned %d posts, want 0", len(ps))
+	}
+}
+
+func testLinkPosts(t *testing.T, s goreddit.Store) {
+	th := createThread(t, s, uuid.Nil)
+	other := createThread(t, s, uuid.Nil)
+
+	link := func(threadID uuid.UUID, url, domain string) goreddit.Post {
+		t.Helper()
+		p := goreddit.Post{ID: uuid.New(), ThreadID: threadID, Title: "Link", Kind: goreddit.PostLink, URL: url, Domain: domain}
+		if err := s.CreatePost(ctx, &p); err != nil {
+			t.Fatalf("CreatePost: %v", err)
+		}
+		time.Sleep(2 * time.Millisecond)
+		return p
+	}
+
+	first := link(th.ID, "https://go.dev/blog/", "go.dev")
+	again := link(th.ID, "https://go.dev/blog/", "go.dev")
+	elsewhere := link(other.ID, "https://go.dev/doc/", "go.dev")
+	link(th.ID, "https://example.com/", "example.com")
+	createPost(t, s, th.ID, uuid.Nil)
+
+	got, err := s.Post(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if got.Kind != goreddit.PostLink || !got.IsLink() || got.URL != "https://go.dev/blog/" || got.Domain != "go.dev" {
+		t.Errorf("Post = %+v, want a link to go.dev", got)
+	}
+
+	ps, err := s.PostsByDomain(ctx, "go.dev", goreddit.PostListing{Sort: goreddit.SortNew}, goreddit.Page{})
+	if err != nil {
+		t.Fatalf("PostsByDomain: %v", err)
+	}
+	assertIDs(t, "domain posts", postIDs(ps), []uuid.UUID{elsewhere.ID, again.ID, first.ID})
+	if ps[0].ThreadTitle != other.Title {
+		t.Errorf("PostsByDomain()[0].ThreadTitle = %q, want %q", ps[0].ThreadTitle, other.Title)
+	}
+
+	page, err := s.PostsByDomain(ctx, "go.dev", goreddit.PostListing{Sort: goreddit.SortNew}, goreddit.Page{After: elsewhere.ID, Limit: 1})
+	if err != nil {
+		t.Fatalf("PostsByDomain: %v", err)
+	}
+	assertIDs(t, "domain posts after first", postIDs(page), []uuid.UUID{again.ID})
+
+	if ps, _ := s.PostsByDomain(ctx, "", goreddit.PostListing{Sort: goreddit.SortNew}, goreddit.Page{}); len(ps) != 0 {
+		t.Errorf("PostsByDomain(\"\") returned %d text posts, want 0", len(ps))
+	}
+
+	got, err = s.PostByURL(ctx, th.ID, "https://go.dev/blog/")
+	if err != nil {
+		t.Fatalf("PostByURL: %v", err)
+	}
+	if got.ID != again.ID {
+		t.Errorf("PostByURL = %s, want the newest post %s", got.ID, again.ID)
+	}
+	if _, err := s.PostByURL(ctx, other.ID, "https://go.dev/blog/"); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("PostByURL in another thread: err = %v, want ErrNotFound", err)
+	}
+	if _, err := s.PostByURL(ctx, th.ID, ""); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("PostByURL with no url: err = %v, want ErrNotFound", err)
+	}
+}
+
+func testImagePosts(t *testing.T, s goreddit.Store) {
+	th := createThread(t, s, uuid.Nil)
+
+	p := goreddit.Post{ID: uuid.New(), ThreadID: th.ID, Title: "Screenshot", Kind: goreddit.PostImage, Image: "images/a.png", Thumbnail: "images/a_thumb.png"}
+	if err := s.CreatePost(ctx, &p); err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	if p.Image != "images/a.png" || p.Thumbnail != "images/a_thumb.png" {
+		t.Errorf("CreatePost returned image %q, thumbnail %q", p.Image, p.Thumbnail)
+	}
+
+	got, err := s.Post(ctx, p.ID)
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if !got.IsImage() || got.Image != "images/a.png" || got.Thumbnail != "images/a_thumb.png" {
+		t.Errorf("Post = %+v, want an image post", got)
+	}
+
+	p.Title = "Screenshot, cropped"
+	if err := s.UpdatePost(ctx, &p); err != nil {
+		t.Fatalf("UpdatePost: %v", err)
+	}
+	ps, err := s.PostsByThead(ctx, th.ID, goreddit.PostListing{Sort: goreddit.SortNew}, goreddit.Page{})
+	if err != nil {
+		t.Fatalf("PostsByThead: %v", err)
+	}
+	if len(ps) != 1 || ps[0].Title != "Screenshot, cropped" || ps[0].Thumbnail != "images/a_thumb.png" {
+		t.Errorf("PostsByThead = %+v, want the updated image post", ps)
+	}
+}
+
+func testUnrendered(t *testing.T, s goreddit.Store) {
+	th := createThread(t, s, uuid.Nil)
+	var want []uuid.UUID
+	for i := 0; i < 3; i++ {
+		want = append(want, createPost(t, s, th.ID, uuid.Nil).ID)
+	}
+	rendered := goreddit.Post{ID: uuid.New(), ThreadID: th.ID, Title: "Rendered", Content: "*hi*", ContentHTML: "<p><em>hi</em></p>"}
+	empty := goreddit.Post{ID: uuid.New(), ThreadID: th.ID, Title: "Empty"}
+	for _, p := range []*goreddit.Post{&rendered, &empty} {
+		if err := s.CreatePost(ctx, p); err != nil {
+			t.Fatalf("CreatePost: %v", err)
+		}
+	}
+	sortUUIDs(want)
+
+	ps, err := s.UnrenderedPosts(ctx, uuid.Nil, 2)
+	if err != nil {
+		t.Fatalf("UnrenderedPosts: %v", err)
+	}
+	if len(ps) != 2 || ps[0].ID != want[0] || ps[1].ID != want[1] || ps[0].Content != "Content" {
+		t.Fatalf("UnrenderedPosts = %+v, want the first two of %v", ps, want)
+	}
+	if ps, _ = s.UnrenderedPosts(ctx, want[1], 2); len(ps) != 1 || ps[0].ID != want[2] {
+		t.Errorf("UnrenderedPosts after %v = %+v, want %v", want[1], ps, want[2])
+	}
+
+	if err := s.SetPostHTML(ctx, want[0], "<p>Content</p>"); err != nil {
+		t.Fatalf("SetPostHTML: %v", err)
+	}
+	got, _ := s.Post(ctx, want[0])
+	if got.ContentHTML != "<p>Content</p>" || got.EditedAt != nil {
+		t.Errorf("Post after SetPostHTML = %+v, want rendered and not edited", got)
+	}
+	if ps, _ = s.UnrenderedPosts(ctx, uuid.Nil, 10); len(ps) != 2 {
+		t.Errorf("UnrenderedPosts after SetPostHTML = %+v, want 2", ps)
+	}
+	if err := s.SetPostHTML(ctx, uuid.New(), "<p></p>"); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("SetPostHTML on a missing post = %v, want ErrNotFound", err)
+	}
+
+	c := goreddit.Comment{ID: uuid.New(), PostID: want[0], Content: "Comment"}
+	if err := s.CreateComment(ctx, &c); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	createComment(t, s, want[0], uuid.Nil, uuid.Nil)
+	cs, err := s.UnrenderedComments(ctx, uuid.Nil, 10)
+	if err != nil {
+		t.Fatalf("UnrenderedComments: %v", err)
+	}
+	if len(cs) != 1 || cs[0].ID != c.ID || cs[0].Content != "Comment" {
+		t.Fatalf("UnrenderedComments = %+v, want %v", cs, c.ID)
+	}
+	if err := s.SetCommentHTML(ctx, c.ID, "<p>Comment</p>"); err != nil {
+		t.Fatalf("SetCommentHTML: %v", err)
+	}
+	if got, _ := s.Comment(ctx, c.ID); got.ContentHTML != "<p>Comment</p>" || got.EditedAt != nil {
+		t.Errorf("Comment after SetCommentHTML = %+v, want rendered and not edited", got)
+	}
+	if cs, _ = s.UnrenderedComments(ctx, uuid.Nil, 10); len(cs) != 0 {
+		t.Errorf("UnrenderedComments after SetCommentHTML = %+v, want none", cs)
+	}
+}
+
+func testComments(t *testing.T, s goreddit.Store) {
+	u := createUser(t, s, "alice")
+	p := createPost(t, s, createThread(t, s, uuid.Nil).ID, u.ID)
+
+	c := goreddit.Comment{ID: uuid.New(), PostID: p.ID, UserID: u.ID, Content: "First", ContentHTML: "<p>First</p>"}
+	if err := s.CreateComment(ctx, &c); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	if err := s.CreateComment(ctx, &goreddit.Comment{ID: uuid.New(), PostID: p.ID, ParentID: uuid.New(), Content: "Orphan"}); err == nil {
+		t.Error("CreateComment accepted a reply to a missing comment")
+	}
+
+	got, err := s.Comment(ctx, c.ID)
+	if err != nil {
+		t.Fatalf("Comment: %v", err)
+	}
+	if got.Content != "First" || got.ContentHTML != "<p>First</p>" || got.Username != "alice" || got.ParentID != uuid.Nil {
+		t.Errorf("Comment = %+v", got)
+	}
+
+	c.Content = "First!"
+	c.ContentHTML = "<p>First!</p>"
+	if err := s.UpdateComment(ctx, &c); err != nil {
+		t.Fatalf("UpdateComment: %v", err)
+	}
+	if got, _ := s.Comment(ctx, c.ID); got.Content != "First!" || got.ContentHTML != "<p>First!</p>" {
+		t.Errorf("Comment content after update = %q, %q; want %q", got.Content, got.ContentHTML, "First!")
+	}
+
+	second := createComment(t, s, p.ID, uuid.Nil, u.ID)
+	vote(t, s.VoteComment, second.ID, u.ID, 1)
+
+	cs, err := s.CommentsByPost(ctx, p.ID, goreddit.Page{})
+	if err != nil {
+		t.Fatalf("CommentsByPost: %v", err)
+	}
+	assertIDs(t, "comments", commentIDs(cs), []uuid.UUID{second.ID, c.ID})
+
+	cs, err = s.CommentsByPost(ctx, p.ID, goreddit.Page{After: second.ID, Limit: 1})
+	if err != nil {
+		t.Fatalf("CommentsByPost: %v", err)
+	}
+	assertIDs(t, "comments after first", commentIDs(cs), []uuid.UUID{c.ID})
+}
+
+func testCommentTree(t *testing.T, s goreddit.Store) {
+	u := createUser(t, s, "alice")
+	p := createPost(t, s, createThread(t, s, uuid.Nil).ID, u.ID)
+
+	top := createComment(t, s, p.ID, uuid.Nil, u.ID)
+	vote(t, s.VoteComment, top.ID, u.ID, 1)
+	low := createComment(t, s, p.ID, uuid.Nil, u.ID)
+	reply := createComment(t, s, p.ID, top.ID, u.ID)
+	nested := createComment(t, s, p.ID, reply.ID, u.ID)
+	upvoted := createComment(t, s, p.ID, top.ID, u.ID)
+	vote(t, s.VoteComment, upvoted.ID, u.ID, 1)
+
+	cs, err := s.CommentTree(ctx, p.ID, goreddit.Page{})
+	if err != nil {
+		t.Fatalf("CommentTree: %v", err)
+	}
+	assertIDs(t, "tree", commentIDs(cs), []uuid.UUID{top.ID, upvoted.ID, reply.ID, nested.ID, low.ID})
+	wantDepths := []int{0, 1, 1, 2, 0}
+	for i, c := range cs {
+		if i < len(wantDepths) && c.Depth != wantDepths[i] {
+			t.Errorf("tree[%d].Depth = %d, want %d", i, c.Depth, wantDepths[i])
+		}
+		if c.ContentHTML != "<p>Comment</p>" {
+			t.Errorf("tree[%d].ContentHTML = %q, want the stored HTML", i, c.ContentHTML)
+		}
+	}
+
+	cs, err = s.CommentTree(ctx, p.ID, goreddit.Page{After: top.ID, Limit: 1})
+	if err != nil {
+		t.Fatalf("CommentTree: %v", err)
+	}
+	assertIDs(t, "tree after first root", commentIDs(cs), []uuid.UUID{low.ID})
+
+	cs, err = s.CommentTree(ctx, p.ID, goreddit.Page{Before: low.ID, Limit: 1})
+	if err != nil {
+		t.Fatalf("CommentTree: %v", err)
+	}
+	assertIDs(t, "tree before last root", commentIDs(cs), []uuid.UUID{top.ID, upvoted.ID, reply.ID, nested.ID})
+}
+
+func testDeleteComment(t *testing.T, s goreddit.Store) {
+	u := createUser(t, s, "alice")
+	p := createPost(t, s, createThread(t, s, uuid.Nil).ID, u.ID)
+
+	parent := createComment(t, s, p.ID, uuid.Nil, u.ID)
+	reply := createComment(t, s, p.ID, parent.ID, u.ID)
+
+	if err := s.DeleteComment(ctx, parent.ID); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	got, err := s.Comment(ctx, parent.ID)
+	if err != nil {
+		t.Fatalf("Comment returned %v for a comment with replies", err)
+	}
+	if !got.Deleted || got.Content != "" || got.ContentHTML != "" || got.UserID != uuid.Nil {
+		t.Errorf("deleted comment with replies = %+v, want a placeholder", got)
+	}
+
+	if err := s.DeleteComment(ctx, reply.ID); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	if _, err := s.Comment(ctx, reply.ID); err == nil {
+		t.Error("Comment returned a deleted reply")
+	}
+	if _, err := s.Comment(ctx, parent.ID); err == nil {
+		t.Error("placeholder was kept after its last reply was deleted")
+	}
+}
+
+func testModerators(t *testing.T, s goreddit.Store) {
+	alice, bob := createUser(t, s, "alice"), createUser(t, s, "bob")
+	th := createThread(t, s, alice.ID)
+
+	isModerator := func(u goreddit.User) bool {
+		t.Helper()
+		ok, err := s.IsModerator(ctx, th.ID, u.ID)
+		if err != nil {
+			t.Fatalf("IsModerator: %v", err)
+		}
+		return ok
+	}
+
+	if !isModerator(alice) {
+		t.Error("thread creator is not a moderator")
+	}
+	if isModerator(bob) {
+		t.Error("bob is a moderator before being added")
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	m := goreddit.ThreadModerator{ThreadID: th.ID, UserID: bob.ID}
+	if err := s.AddModerator(ctx, &m); err != nil {
+		t.Fatalf("AddModerator: %v", err)
+	}
+	if m.CreatedAt.IsZero() {
+		t.Error("AddModerator did not set CreatedAt")
+	}
+	if !isModerator(bob) {
+		t.Error("bob is not a moderator after being added")
+	}
+	if err := s.AddModerator(ctx, &m); !errors.Is(err, goreddit.ErrConflict) {
+		t.Errorf("adding a moderator twice returned %v, want ErrConflict", err)
+	}
+	missing := goreddit.ThreadModerator{ThreadID: uuid.New(), UserID: bob.ID}
+	if err := s.AddModerator(ctx, &missing); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("AddModerator for a missing thread returned %v, want ErrNotFound", err)
+	}
+
+	ms, err := s.Moderators(ctx, th.ID)
+	if err != nil {
+		t.Fatalf("Moderators: %v", err)
+	}
+	if len(ms) != 2 || ms[0].UserID != alice.ID || ms[1].UserID != bob.ID || ms[0].Username != "alice" {
+		t.Errorf("Moderators = %+v, want alice then bob", ms)
+	}
+
+	if err := s.RemoveModerator(ctx, th.ID, alice.ID); err != nil {
+		t.Fatalf("RemoveModerator: %v", err)
+	}
+	if isModerator(alice) {
+		t.Error("alice is still a moderator after being removed")
+	}
+	if err := s.RemoveModerator(ctx, th.ID, alice.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("removing a moderator twice returned %v, want ErrNotFound", err)
+	}
+
+	if err := s.DeleteUser(ctx, bob.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if ms, err := s.Moderators(ctx, th.ID); err != nil || len(ms) != 0 {
+		t.Errorf("Moderators after deleting the last one = %+v, %v; want none", ms, err)
+	}
+
+	if ms, err := s.Moderators(ctx, createThread(t, s, uuid.Nil).ID); err != nil || len(ms) != 0 {
+		t.Errorf("Moderators of a thread without a creator = %+v, %v; want none", ms, err)
+	}
+}
+
+func testModLog(t *testing.T, s goreddit.Store) {
+	alice, bob := createUser(t, s, "alice"), createUser(t, s, "bob")
+	th := createThread(t, s, alice.ID)
+	other := createThread(t, s, alice.ID)
+	p := createPost(t, s, th.ID, bob.ID)
+
+	log := func(threadID, actorID uuid.UUID, action goreddit.ModAction) goreddit.ModLogEntry {
+		t.Helper()
+		time.Sleep(2 * time.Millisecond)
+		e := goreddit.ModLogEntry{
+			ID:           uuid.New(),
+			ThreadID:     threadID,
+			ActorID:      actorID,
+			Action:       action,
+			TargetUserID: bob.ID,
+			PostID:       p.ID,
+			Target:       p.Title,
+			Reason:       "spam",
+		}
+		if err := s.LogModAction(ctx, &e); err != nil {
+			t.Fatalf("LogModAction: %v", err)
+		}
+		if e.CreatedAt.IsZero() {
+			t.Error("LogModAction did not set CreatedAt")
+		}
+		return e
+	}
+
+	lock := log(th.ID, alice.ID, goreddit.ModLockPost)
+	pin := log(th.ID, bob.ID, goreddit.ModPinPost)
+	remove := log(th.ID, alice.ID, goreddit.ModRemovePost)
+	log(other.ID, alice.ID, goreddit.ModRemovePost)
+
+	modLog := func(f goreddit.ModLogFilter, p goreddit.Page) []goreddit.ModLogEntry {
+		t.Helper()
+		es, err := s.ModLog(ctx, th.ID, f, p)
+		if err != nil {
+			t.Fatalf("ModLog: %v", err)
+		}
+		return es
+	}
+	es := modLog(goreddit.ModLogFilter{}, goreddit.Page{})
+	assertIDs(t, "ModLog", modLogIDs(es), []uuid.UUID{remove.ID, pin.ID, lock.ID})
+	if len(es) == 0 {
+		t.FailNow()
+	}
+	if e := es[0]; e.ActorName != "alice" || e.TargetName != "bob" || e.PostID != p.ID || e.Target != p.Title || e.Reason != "spam" || e.CommentID != uuid.Nil {
+		t.Errorf("ModLog entry = %+v", e)
+	}
+
+	assertIDs(t, "ModLog by action", modLogIDs(modLog(goreddit.ModLogFilter{Action: goreddit.ModRemovePost}, goreddit.Page{})), []uuid.UUID{remove.ID})
+	assertIDs(t, "ModLog by moderator", modLogIDs(modLog(goreddit.ModLogFilter{ActorID: alice.ID}, goreddit.Page{})), []uuid.UUID{remove.ID, lock.ID})
+	assertIDs(t, "ModLog by action and moderator", modLogIDs(modLog(goreddit.ModLogFilter{Action: goreddit.ModPinPost, ActorID: alice.ID}, goreddit.Page{})), []uuid.UUID{})
+
+	assertIDs(t, "ModLog after", modLogIDs(modLog(goreddit.ModLogFilter{}, goreddit.Page{After: remove.ID, Limit: 1})), []uuid.UUID{pin.ID})
+	assertIDs(t, "ModLog before", modLogIDs(modLog(goreddit.ModLogFilter{}, goreddit.Page{Before: lock.ID})), []uuid.UUID{remove.ID, pin.ID})
+
+	missing := goreddit.ModLogEntry{ID: uuid.New(), ThreadID: uuid.New(), ActorID: alice.ID, Action: goreddit.ModLockPost}
+	if err := s.LogModAction(ctx, &missing); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("LogModAction for a missing thread returned %v, want ErrNotFound", err)
+	}
+
+	// Deleting the content or the users involved keeps the log.
+	if err := s.DeletePost(ctx, p.ID); err != nil {
+		t.Fatalf("DeletePost: %v", err)
+	}
+	if err := s.DeleteUser(ctx, bob.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	es = modLog(goreddit.ModLogFilter{}, goreddit.Page{})
+	assertIDs(t, "ModLog after deleting the post and its author", modLogIDs(es), []uuid.UUID{remove.ID, pin.ID, lock.ID})
+	if len(es) != 3 {
+		t.FailNow()
+	}
+	if e := es[1]; e.ActorID != uuid.Nil || e.ActorName != "" || e.TargetUserID != uuid.Nil || e.PostID != p.ID {
+		t.Errorf("ModLog entry by a deleted user = %+v", e)
+	}
+
+	if err := s.DeleteThread(ctx, th.ID); err != nil {
+		t.Fatalf("DeleteThread: %v", err)
+	}
+	assertIDs(t, "ModLog of a deleted thread", modLogIDs(modLog(goreddit.ModLogFilter{}, goreddit.Page{})), []uuid.UUID{})
+}
+
+func testReports(t *testing.T, s goreddit.Store) {
+	alice, bob, carol := createUser(t, s, "alice"), createUser(t, s, "bob"), createUser(t, s, "carol")
+	th := createThread(t, s, alice.ID)
+	p := createPost(t, s, th.ID, alice.ID)
+	c := createComment(t, s, p.ID, uuid.Nil, bob.ID)
+	other := createPost(t, s, createThread(t, s, alice.ID).ID, alice.ID)
+
+	report := func(u goreddit.User, postID, commentID uuid.UUID, reason string) error {
+		t.Helper()
+		time.Sleep(2 * time.Millisecond)
+		r := goreddit.Report{ID: uuid.New(), PostID: postID, CommentID: commentID, UserID: u.ID, Reason: reason}
+		err := s.CreateReport(ctx, &r)
+		if err == nil && r.CreatedAt.IsZero() {
+			t.Error("CreateReport did not set CreatedAt")
+		}
+		return err
+	}
+	for _, err := range []error{
+		report(bob, p.ID, uuid.Nil, "spam"),
+		report(carol, p.ID, c.ID, "rude"),
+		report(alice, p.ID, c.ID, "off topic"),
+		report(carol, other.ID, uuid.Nil, "spam"),
+	} {
+		if err != nil {
+			t.Fatalf("CreateReport: %v", err)
+		}
+	}
+	if err := report(bob, p.ID, uuid.Nil, "again"); !errors.Is(err, goreddit.ErrConflict) {
+		t.Errorf("reporting a post twice returned %v, want ErrConflict", err)
+	}
+	if err := report(bob, p.ID, c.ID, "rude"); err != nil {
+		t.Errorf("reporting a comment on a post the user reported: %v", err)
+	}
+	if err := report(bob, uuid.New(), uuid.Nil, "spam"); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("reporting a missing post returned %v, want ErrNotFound", err)
+	}
+
+	queue := func() []goreddit.ReportedItem {
+		t.Helper()
+		items, err := s.ReportQueue(ctx, th.ID)
+		if err != nil {
+			t.Fatalf("ReportQueue: %v", err)
+		}
+		return items
+	}
+
+	items := queue()
+	if len(items) != 2 {
+		t.Fatalf("ReportQueue returned %d items, want 2", len(items))
+	}
+	if i := items[0]; !i.IsComment() || i.CommentID != c.ID || i.PostID != p.ID || i.Content != c.Content || i.UserID != bob.ID || i.Username != "bob" || i.PostTitle != p.Title {
+		t.Errorf("most reported item = %+v, want the comment", i)
+	}
+	if rs := items[0].Reports; len(rs) != 3 || rs[0].Reason != "rude" || rs[1].Reason != "off topic" || rs[0].UserID != carol.ID {
+		t.Errorf("comment reports = %+v, want carol's, alice's and bob's, oldest first", rs)
+	}
+	if i := items[1]; i.IsComment() || i.PostID != p.ID || i.Content != p.Content || i.Username != "alice" || len(i.Reports) != 1 {
+		t.Errorf("second item = %+v, want the post", i)
+	}
+
+	if err := s.ClearReports(ctx, p.ID, uuid.Nil); err != nil {
+		t.Fatalf("ClearReports: %v", err)
+	}
+	if items := queue(); len(items) != 1 || items[0].CommentID != c.ID {
+		t.Errorf("ReportQueue after clearing the post = %+v, want only the comment", items)
+	}
+
+	// A deleted comment that is kept for its replies leaves the queue.
+	createComment(t, s, p.ID, c.ID, alice.ID)
+	if err := s.DeleteComment(ctx, c.ID); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	if items := queue(); len(items) != 0 {
+		t.Errorf("ReportQueue after deleting the comment = %+v, want none", items)
+	}
+
+	if err := report(alice, p.ID, uuid.Nil, "spam"); err != nil {
+		t.Fatalf("CreateReport: %v", err)
+	}
+	if err := s.DeletePost(ctx, p.ID); err != nil {
+		t.Fatalf("DeletePost: %v", err)
+	}
+	if items := queue(); len(items) != 0 {
+		t.Errorf("ReportQueue after deleting the post = %+v, want none", items)
+	}
+}
+
+func testBans(t *testing.T, s goreddit.Store) {
+	alice, bob, carol := createUser(t, s, "alice"), createUser(t, s, "bob"), createUser(t, s, "carol")
+	th := createThread(t, s, alice.ID)
+
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	ban := func(u goreddit.User, expiresAt *time.Time, reason string) {
+		t.Helper()
+		time.Sleep(2 * time.Millisecond)
+		b := goreddit.ThreadBan{ThreadID: th.ID, UserID: u.ID, BannedBy: alice.ID, Reason: reason, ExpiresAt: expiresAt}
+		if err := s.BanUser(ctx, &b); err != nil {
+			t.Fatalf("BanUser: %v", err)
+		}
+		if b.CreatedAt.IsZero() {
+			t.Error("BanUser did not set CreatedAt")
+		}
+	}
+
+	if _, err := s.ThreadBan(ctx, th.ID, bob.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("ThreadBan before banning returned %v, want ErrNotFound", err)
+	}
+
+	ban(bob, nil, "trolling")
+	ban(carol, &past, "spam")
+	b, err := s.ThreadBan(ctx, th.ID, bob.ID)
+	if err != nil {
+		t.Fatalf("ThreadBan: %v", err)
+	}
+	if b.Reason != "trolling" || b.BannedBy != alice.ID || b.ExpiresAt != nil || b.Username != "bob" {
+		t.Errorf("ThreadBan = %+v", b)
+	}
+	if _, err := s.ThreadBan(ctx, th.ID, carol.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("ThreadBan after the ban expired returned %v, want ErrNotFound", err)
+	}
+	if _, err := s.ThreadBan(ctx, createThread(t, s, alice.ID).ID, bob.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("ThreadBan in another thread returned %v, want ErrNotFound", err)
+	}
+
+	ban(carol, &future, "spam again")
+	if b, err := s.ThreadBan(ctx, th.ID, carol.ID); err != nil || b.Reason != "spam again" || b.ExpiresAt == nil || !b.ExpiresAt.Round(time.Second).Equal(future.Round(time.Second)) {
+		t.Errorf("ThreadBan after banning again = %+v, %v; want the new ban", b, err)
+	}
+
+	bs, err := s.ThreadBans(ctx, th.ID)
+	if err != nil {
+		t.Fatalf("ThreadBans: %v", err)
+	}
+	if len(bs) != 2 || bs[0].UserID != carol.ID || bs[1].UserID != bob.ID {
+		t.Errorf("ThreadBans = %+v, want carol then bob", bs)
+	}
+
+	if err := s.UnbanUser(ctx, th.ID, bob.ID); err != nil {
+		t.Fatalf("UnbanUser: %v", err)
+	}
+	if _, err := s.ThreadBan(ctx, th.ID, bob.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("ThreadBan after unbanning returned %v, want ErrNotFound", err)
+	}
+	if err := s.UnbanUser(ctx, th.ID, bob.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("unbanning twice returned %v, want ErrNotFound", err)
+	}
+	missing := goreddit.ThreadBan{ThreadID: uuid.New(), UserID: bob.ID}
+	if err := s.BanUser(ctx, &missing); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("BanUser in a missing thread returned %v, want ErrNotFound", err)
+	}
+
+	if err := s.DeleteUser(ctx, alice.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if b, err := s.ThreadBan(ctx, th.ID, carol.ID); err != nil || b.BannedBy != uuid.Nil {
+		t.Errorf("ThreadBan after deleting the moderator = %+v, %v; want the ban to stay", b, err)
+	}
+	if err := s.DeleteUser(ctx, carol.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if bs, err := s.ThreadBans(ctx, th.ID); err != nil || len(bs) != 0 {
+		t.Errorf("ThreadBans after deleting the banned user = %+v, %v; want none", bs, err)
+	}
+}
+
+func testSuspensions(t *testing.T, s goreddit.Store) {
+	admin, bob := createUser(t, s, "admin"), createUser(t, s, "bob")
+
+	if _, err := s.Suspension(ctx, bob.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Suspension before suspending returned %v, want ErrNotFound", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	su := goreddit.Suspension{UserID: bob.ID, SuspendedBy: admin.ID, Reason: "spam", ExpiresAt: &past}
+	if err := s.SuspendUser(ctx, &su); err != nil {
+		t.Fatalf("SuspendUser: %v", err)
+	}
+	if su.CreatedAt.IsZero() {
+		t.Error("SuspendUser did not set CreatedAt")
+	}
+	if _, err := s.Suspension(ctx, bob.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Suspension after it expired returned %v, want ErrNotFound", err)
+	}
+
+	su = goreddit.Suspension{UserID: bob.ID, SuspendedBy: admin.ID, Reason: "more spam"}
+	if err := s.SuspendUser(ctx, &su); err != nil {
+		t.Fatalf("SuspendUser: %v", err)
+	}
+	got, err := s.Suspension(ctx, bob.ID)
+	if err != nil {
+		t.Fatalf("Suspension: %v", err)
+	}
+	if got.Reason != "more spam" || got.SuspendedBy != admin.ID || got.ExpiresAt != nil {
+		t.Errorf("Suspension = %+v, want the new suspension", got)
+	}
+
+	if err := s.UnsuspendUser(ctx, bob.ID); err != nil {
+		t.Fatalf("UnsuspendUser: %v", err)
+	}
+	if _, err := s.Suspension(ctx, bob.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Suspension after unsuspending returned %v, want ErrNotFound", err)
+	}
+	if err := s.UnsuspendUser(ctx, bob.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("unsuspending twice returned %v, want ErrNotFound", err)
+	}
+	missing := goreddit.Suspension{UserID: uuid.New()}
+	if err := s.SuspendUser(ctx, &missing); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("SuspendUser for a missing user returned %v, want ErrNotFound", err)
+	}
+}
+
+func testLockAndPin(t *testing.T, s goreddit.Store) {
+	u := createUser(t, s, "alice")
+	th := createThread(t, s, u.ID)
+
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		ids = append([]uuid.UUID{createPost(t, s, th.ID, u.ID).ID}, ids...)
+		time.Sleep(2 * time.Millisecond)
+	}
+	oldest := ids[2]
+
+	if err := s.LockPost(ctx, oldest, true); err != nil {
+		t.Fatalf("LockPost: %v", err)
+	}
+	if err := s.PinPost(ctx, oldest, true); err != nil {
+		t.Fatalf("PinPost: %v", err)
+	}
+	p, err := s.Post(ctx, oldest)
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if !p.Locked || !p.Pinned {
+		t.Errorf("post after LockPost and PinPost = %+v, want locked and pinned", p)
+	}
+
+	p.Title = "Edited"
+	if err := s.UpdatePost(ctx, &p); err != nil {
+		t.Fatalf("UpdatePost: %v", err)
+	}
+	if !p.Locked || !p.Pinned {
+		t.Error("UpdatePost cleared the locked and pinned flags")
+	}
+
+	for _, sort := range []goreddit.PostSort{goreddit.SortNew, goreddit.SortTop} {
+		ps, err := s.PostsByThead(ctx, th.ID, goreddit.PostListing{Sort: sort}, goreddit.Page{})
+		if err != nil {
+			t.Fatalf("PostsByThead: %v", err)
+		}
+		if len(ps) != 3 || ps[0].ID != oldest {
+			t.Errorf("%s listing = %v, want the pinned post first", sort, postIDs(ps))
+		}
+	}
+
+	ps, err := s.PostsByThead(ctx, th.ID, goreddit.PostListing{Sort: goreddit.SortNew}, goreddit.Page{After: oldest, Limit: 5})
+	if err != nil {
+		t.Fatalf("PostsByThead: %v", err)
+	}
+	assertIDs(t, "posts after pinned post", postIDs(ps), ids[:2])
+
+	ps, err = s.Posts(ctx, goreddit.PostListing{Sort: goreddit.SortNew}, goreddit.Page{})
+	if err != nil {
+		t.Fatalf("Posts: %v", err)
+	}
+	assertIDs(t, "front page", postIDs(ps), ids)
+
+	if err := s.LockPost(ctx, oldest, false); err != nil {
+		t.Fatalf("LockPost: %v", err)
+	}
+	if err := s.PinPost(ctx, oldest, false); err != nil {
+		t.Fatalf("PinPost: %v", err)
+	}
+	if p, _ := s.Post(ctx, oldest); p.Locked || p.Pinned {
+		t.Errorf("post after unlocking and unpinning = %+v", p)
+	}
+
+	if err := s.LockPost(ctx, uuid.New(), true); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("LockPost on a missing post returned %v, want ErrNotFound", err)
+	}
+	if err := s.PinPost(ctx, uuid.New(), true); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("PinPost on a missing post returned %v, want ErrNotFound", err)
+	}
+}
+
+func testUsers(t *testing.T, s goreddit.Store) {
+	u := createUser(t, s, "alice")
+	if u.Role != goreddit.RoleUser {
+		t.Errorf("new user role = %q, want %q", u.Role, goreddit.RoleUser)
+	}
+
+	if err := s.CreateUser(ctx, &goreddit.User{ID: uuid.New(), Username: "alice", Password: "x"}); err == nil {
+		t.Error("CreateUser accepted a duplicate username")
+	}
+
+	got, err := s.UserByUsername(ctx, "alice")
+	if err != nil {
+		t.Fatalf("UserByUsername: %v", err)
+	}
+	if got.ID != u.ID {
+		t.Errorf("UserByUsername returned user %v, want %v", got.ID, u.ID)
+	}
+	if _, err := s.UserByUsername(ctx, "bob"); err == nil {
+		t.Error("UserByUsername returned no error for a missing user")
+	}
+
+	bob := createUser(t, s, "bob")
+	bob.Username = "alice"
+	if err := s.UpdateUser(ctx, &bob); err == nil {
+		t.Error("UpdateUser accepted a duplicate username")
+	}
+
+	u.Role = goreddit.RoleAdmin
+	if err := s.UpdateUser(ctx, &u); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if got, _ := s.User(ctx, u.ID); !got.IsAdmin() {
+		t.Errorf("user role after update = %q, want %q", got.Role, goreddit.RoleAdmin)
+	}
+}
+
+func testUserHistory(t *testing.T, s goreddit.Store) {
+	alice, bob := createUser(t, s, "alice"), createUser(t, s, "bob")
+	th := createThread(t, s, alice.ID)
+
+	var posts, comments []uuid.UUID
+	for i := 0; i < 3; i++ {
+		p := createPost(t, s, th.ID, alice.ID)
+		c := createComment(t, s, p.ID, uuid.Nil, alice.ID)
+		posts = append([]uuid.UUID{p.ID}, posts...)
+		comments = append([]uuid.UUID{c.ID}, comments...)
+		// History is listed newest first, so it needs distinct times.
+		time.Sleep(2 * time.Millisecond)
+	}
+	other := createPost(t, s, th.ID, bob.ID)
+	createComment(t, s, other.ID, uuid.Nil, bob.ID)
+
+	ps, err := s.PostsByUser(ctx, alice.ID, goreddit.Page{})
+	if err != nil {
+		t.Fatalf("PostsByUser: %v", err)
+	}
+	assertIDs(t, "posts by user", postIDs(ps), posts)
+	if ps[0].ThreadTitle != th.Title || ps[0].Username != "alice" || ps[0].CommentsCount != 1 {
+		t.Errorf("PostsByUser()[0] = %+v, want thread title, username and comment count", ps[0])
+	}
+
+	ps, err = s.PostsByUser(ctx, alice.ID, goreddit.Page{After: posts[0], Limit: 1})
+	if err != nil {
+		t.Fatalf("PostsByUser: %v", err)
+	}
+	assertIDs(t, "posts after newest", postIDs(ps), posts[1:2])
+
+	ps, err = s.PostsByUser(ctx, alice.ID, goreddit.Page{Before: posts[2], Limit: 1})
+	if err != nil {
+		t.Fatalf("PostsByUser: %v", err)
+	}
+	assertIDs(t, "posts before oldest", postIDs(ps), posts[1:2])
+
+	cs, err := s.CommentsByUser(ctx, alice.ID, goreddit.Page{})
+	if err != nil {
+		t.Fatalf("CommentsByUser: %v", err)
+	}
+	assertIDs(t, "comments by user", commentIDs(cs), comments)
+	if cs[0].PostTitle != "Post" || cs[0].Username != "alice" {
+		t.Errorf("CommentsByUser()[0] = %+v, want post title and username", cs[0])
+	}
+
+	cs, err = s.CommentsByUser(ctx, alice.ID, goreddit.Page{After: comments[1], Limit: 5})
+	if err != nil {
+		t.Fatalf("CommentsByUser: %v", err)
+	}
+	assertIDs(t, "comments after second", commentIDs(cs), comments[2:])
+
+	if err := s.DeleteComment(ctx, comments[0]); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	cs, err = s.CommentsByUser(ctx, alice.ID, goreddit.Page{})
+	if err != nil {
+		t.Fatalf("CommentsByUser: %v", err)
+	}
+	assertIDs(t, "comments after delete", commentIDs(cs), comments[1:])
+}
+
+func testDeleteUser(t *testing.T, s goreddit.Store) {
+	u := createUser(t, s, "alice")
+	th := createThread(t, s, u.ID)
+	p := createPost(t, s, th.ID, u.ID)
+	c := createComment(t, s, p.ID, uuid.Nil, u.ID)
+	vote(t, s.VotePost, p.ID, u.ID, 1)
+	createToken(t, s, u.ID)
+
+	if err := s.DeleteUser(ctx, u.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if _, err := s.User(ctx, u.ID); err == nil {
+		t.Error("User returned a deleted user")
+	}
+
+	gotThread, err := s.Thread(ctx, th.ID)
+	if err != nil || gotThread.UserID != uuid.Nil {
+		t.Errorf("Thread after deleting its owner = %+v, %v; want it kept without an owner", gotThread, err)
+	}
+	gotPost, err := s.Post(ctx, p.ID)
+	if err != nil || gotPost.UserID != uuid.Nil || gotPost.Username != "" {
+		t.Errorf("Post after deleting its author = %+v, %v; want it kept without an author", gotPost, err)
+	}
+	if gotPost.Votes != 1 {
+		t.Errorf("Post votes after deleting a voter = %d, want 1", gotPost.Votes)
+	}
+	gotComment, err := s.Comment(ctx, c.ID)
+	if err != nil || gotComment.UserID != uuid.Nil {
+		t.Errorf("Comment after deleting its author = %+v, %v; want it kept without an author", gotComment, err)
+	}
+	if ts, _ := s.APITokens(ctx, u.ID); len(ts) != 0 {
+		t.Errorf("APITokens after deleting the user returned %d tokens, want 0", len(ts))
+	}
+}
+
+func testVotes(t *testing.T, s goreddit.Store) {
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	p := createPost(t, s, createThread(t, s, uuid.Nil).ID, alice.ID)
+	c := createComment(t, s, p.ID, uuid.Nil, alice.ID)
+
+	steps := []struct {
+		user                      goreddit.User
+		value                     int
+		votes, upvotes, downvotes int
+	}{
+		{alice, 1, 1, 1, 0},
+		{alice, 1, 1, 1, 0},
+		{bob, -1, 0, 1, 1},
+		{alice, -1, -2, 0, 2},
+		{bob, 0, -1, 0, 1},
+	}
+	for i, st := range steps {
+		vote(t, s.VotePost, p.ID, st.user.ID, st.value)
+		vote(t, s.VoteComment, c.ID, st.user.ID, st.value)
+
+		gotPost, _ := s.Post(ctx, p.ID)
+		if gotPost.Votes != st.votes || gotPost.Upvotes != st.upvotes || gotPost.Downvotes != st.downvotes {
+			t.Errorf("step %d: post tallies = %d/%d/%d, want %d/%d/%d", i, gotPost.Votes, gotPost.Upvotes, gotPost.Downvotes, st.votes, st.upvotes, st.downvotes)
+		}
+		gotComment, _ := s.Comment(ctx, c.ID)
+		if gotComment.Votes != st.votes || gotComment.Upvotes != st.upvotes || gotComment.Downvotes != st.downvotes {
+			t.Errorf("step %d: comment tallies = %d/%d/%d, want %d/%d/%d", i, gotComment.Votes, gotComment.Upvotes, gotComment.Downvotes, st.votes, st.upvotes, st.downvotes)
+		}
+		if v, err := s.PostVote(ctx, p.ID, st.user.ID); err != nil || v != st.value {
+			t.Errorf("step %d: PostVote = %d, %v; want %d", i, v, err, st.value)
+		}
+		if v, err := s.CommentVote(ctx, c.ID, st.user.ID); err != nil || v != st.value {
+			t.Errorf("step %d: CommentVote = %d, %v; want %d", i, v, err, st.value)
+		}
+	}
+
+	if err := s.VotePost(ctx, p.ID, alice.ID, 2); err == nil {
+		t.Error("VotePost accepted a vote of 2")
+	}
+	if v, err := s.PostVote(ctx, p.ID, createUser(t, s, "carol").ID); err != nil || v != 0 {
+		t.Errorf("PostVote for a user who has not voted = %d, %v; want 0", v, err)
+	}
+}
+
+func testToggleVotes(t *testing.T, s goreddit.Store) {
+	alice, bob := createUser(t, s, "alice"), createUser(t, s, "bob")
+	p := createPost(t, s, createThread(t, s, uuid.Nil).ID, alice.ID)
+	c := createComment(t, s, p.ID, uuid.Nil, alice.ID)
+
+	steps := []struct {
+		value, want int
+	}{
+		{1, 1},
+		{1, 0},
+		{-1, -1},
+		{1, 1},
+		{-1, -1},
+		{-1, 0},
+	}
+	for i, st := range steps {
+		if v, err := s.TogglePostVote(ctx, p.ID, bob.ID, st.value); err != nil || v != st.want {
+			t.Errorf("step %d: TogglePostVote(%d) = %d, %v; want %d", i, st.value, v, err, st.want)
+		}
+		if v, err := s.ToggleCommentVote(ctx, c.ID, bob.ID, st.value); err != nil || v != st.want {
+			t.Errorf("step %d: ToggleCommentVote(%d) = %d, %v; want %d", i, st.value, v, err, st.want)
+		}
+		if v, _ := s.PostVote(ctx, p.ID, bob.ID); v != st.want {
+			t.Errorf("step %d: PostVote = %d, want %d", i, v, st.want)
+		}
+		if got, _ := s.Post(ctx, p.ID); got.Votes != st.want {
+			t.Errorf("step %d: post votes = %d, want %d", i, got.Votes, st.want)
+		}
+	}
+
+	// Clicks that race each other still take turns: an even number of them
+	// leaves no vote, not several.
+	const clicks = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, clicks)
+	for i := 0; i < clicks; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.TogglePostVote(ctx, p.ID, bob.ID, 1); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("TogglePostVote: %v", err)
+	}
+	if v, _ := s.PostVote(ctx, p.ID, bob.ID); v != 0 {
+		t.Errorf("PostVote after %d clicks = %d, want 0", clicks, v)
+	}
+	got, _ := s.Post(ctx, p.ID)
+	if got.Votes != 0 || got.Upvotes != 0 || got.Downvotes != 0 {
+		t.Errorf("post tallies after %d clicks = %d/%d/%d, want none", clicks, got.Votes, got.Upvotes, got.Downvotes)
+	}
+	if u, _ := s.User(ctx, alice.ID); u.PostKarma != 0 {
+		t.Errorf("author's post karma after %d clicks = %d, want 0", clicks, u.PostKarma)
+	}
+
+	if _, err := s.TogglePostVote(ctx, p.ID, bob.ID, 2); err == nil {
+		t.Error("TogglePostVote accepted a vote of 2")
+	}
+	if _, err := s.ToggleCommentVote(ctx, uuid.New(), bob.ID, 1); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("ToggleCommentVote on a missing comment = %v, want ErrNotFound", err)
+	}
+}
+
+func testKarma(t *testing.T, s goreddit.Store) {
+	alice, bob, carol := createUser(t, s, "alice"), createUser(t, s, "bob"), createUser(t, s, "carol")
+	p := createPost(t, s, createThread(t, s, uuid.Nil).ID, alice.ID)
+	c := createComment(t, s, p.ID, uuid.Nil, alice.ID)
+
+	steps := []struct {
+		name                    string
+		f                       func(ctx context.Context, targetID, userID uuid.UUID, value int) error
+		targetID                uuid.UUID
+		user                    goreddit.User
+		value                   int
+		postKarma, commentKarma int
+	}{
+		{"upvote post", s.VotePost, p.ID, bob, 1, 1, 0},
+		{"upvote own post", s.VotePost, p.ID, alice, 1, 1, 0},
+		{"downvote post", s.VotePost, p.ID, carol, -1, 0, 0},
+		{"switch vote on post", s.VotePost, p.ID, bob, -1, -2, 0},
+		{"upvote comment", s.VoteComment, c.ID, carol, 1, -2, 1},
+		{"downvote own comment", s.VoteComment, c.ID, alice, -1, -2, 1},
+		{"withdraw vote on comment", s.VoteComment, c.ID, carol, 0, -2, 0},
+		{"upvote comment again", s.VoteComment, c.ID, bob, 1, -2, 1},
+	}
+	for _, st := range steps {
+		vote(t, st.f, st.targetID, st.user.ID, st.value)
+
+		u, err := s.User(ctx, alice.ID)
+		if err != nil {
+			t.Fatalf("User: %v", err)
+		}
+		if u.PostKarma != st.postKarma || u.CommentKarma != st.commentKarma {
+			t.Errorf("%s: karma = %d/%d, want %d/%d", st.name, u.PostKarma, u.CommentKarma, st.postKarma, st.commentKarma)
+		}
+	}
+
+	for _, u := range []goreddit.User{bob, carol} {
+		got, err := s.User(ctx, u.ID)
+		if err != nil {
+			t.Fatalf("User: %v", err)
+		}
+		if got.Karma() != 0 {
+			t.Errorf("%s has %d karma from voting, want 0", u.Username, got.Karma())
+		}
+	}
+
+	if err := s.DeletePost(ctx, p.ID); err != nil {
+		t.Fatalf("DeletePost: %v", err)
+	}
+	u, err := s.User(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("User: %v", err)
+	}
+	if u.Karma() != -1 {
+		t.Errorf("karma after deleting post = %d, want -1", u.Karma())
+	}
+}
+
+func testAPITokens(t *testing.T, s goreddit.Store) {
+	u := createUser(t, s, "alice")
+	first := createToken(t, s, u.ID)
+	time.Sleep(2 * time.Millisecond)
+	second := createToken(t, s, u.ID)
+
+	if err := s.CreateAPIToken(ctx, &goreddit.APIToken{ID: uuid.New(), UserID: u.ID, Name: "copy", Hash: first.Hash}); err == nil {
+		t.Error("CreateAPIToken accepted a duplicate hash")
+	}
+
+	ts, err := s.APITokens(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("APITokens: %v", err)
+	}
+	assertIDs(t, "tokens", tokenIDs(ts), []uuid.UUID{second.ID, first.ID})
+
+	used, err := s.UseAPIToken(ctx, first.Hash)
+	if err != nil {
+		t.Fatalf("UseAPIToken: %v", err)
+	}
+	if used.ID != first.ID || used.UserID != u.ID || used.LastUsedAt == nil {
+		t.Errorf("UseAPIToken = %+v, want token %v marked as used", used, first.ID)
+	}
+	if _, err := s.UseAPIToken(ctx, []byte("unknown")); err == nil {
+		t.Error("UseAPIToken returned no error for an unknown hash")
+	}
+
+	if err := s.DeleteAPIToken(ctx, first.ID); err != nil {
+		t.Fatalf("DeleteAPIToken: %v", err)
+	}
+	if _, err := s.UseAPIToken(ctx, first.Hash); err == nil {
+		t.Error("UseAPIToken accepted a revoked token")
+	}
+}
+
+func testSearch(t *testing.T, s goreddit.Store) {
+	alice, bob := createUser(t, s, "alice"), createUser(t, s, "bob")
+	golang := goreddit.Thread{ID: uuid.New(), UserID: alice.ID, Title: "Golang", Description: "Go"}
+	if err := s.CreateThread(ctx, &golang); err != nil {
+		t.Fatalf("CreateThread: %v", err)
+	}
+	other := createThread(t, s, alice.ID)
+
+	inTitle := goreddit.Post{ID: uuid.New(), ThreadID: golang.ID, UserID: alice.ID, Title: "Generics proposal", Content: "The generics proposal was accepted."}
+	inContent := goreddit.Post{ID: uuid.New(), ThreadID: other.ID, UserID: bob.ID, Title: "News", Content: "Did you hear about generics?"}
+	unrelated := goreddit.Post{ID: uuid.New(), ThreadID: golang.ID, UserID: bob.ID, Title: "Modules", Content: "Proposal for modules."}
+	for _, p := range []*goreddit.Post{&inTitle, &inContent, &unrelated} {
+		if err := s.CreatePost(ctx, p); err != nil {
+			t.Fatalf("CreatePost: %v", err)
+		}
+	}
+	c := goreddit.Comment{ID: uuid.New(), PostID: unrelated.ID, UserID: alice.ID, Content: "Generics first, please."}
+	if err := s.CreateComment(ctx, &c); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	search := func(q goreddit.SearchQuery) []goreddit.SearchResult {
+		t.Helper()
+		rs, err := s.Search(ctx, q, 10, 0)
+		if err != nil {
+			t.Fatalf("Search(%+v): %v", q, err)
+		}
+		return rs
+	}
+
+	rs := search(goreddit.SearchQuery{Phrases: []string{"generics"}})
+	assertIDs(t, "search results", searchIDs(rs), []uuid.UUID{inTitle.ID, c.ID, inContent.ID})
+	if rs[0].ThreadTitle != "Golang" || rs[0].Username != "alice" || rs[0].PostTitle != inTitle.Title {
+		t.Errorf("first result = %+v, want thread, author and post title", rs[0])
+	}
+	if rs[1].PostID != unrelated.ID || rs[1].CommentID != c.ID {
+		t.Errorf("comment result = %+v, want its post and comment IDs", rs[1])
+	}
+	for _, r := range rs {
+		if !strings.Contains(r.Snippet, goreddit.MatchStart) || !strings.Contains(r.Snippet, goreddit.MatchEnd) {
+			t.Errorf("snippet %q does not mark the match", r.Snippet)
+		}
+	}
+
+	rs = search(goreddit.SearchQuery{Phrases: []string{"generics proposal"}})
+	assertIDs(t, "phrase results", searchIDs(rs), []uuid.UUID{inTitle.ID})
+
+	rs = search(goreddit.SearchQuery{Phrases: []string{"generics", "proposal"}})
+	assertIDs(t, "all-phrase results", searchIDs(rs), []uuid.UUID{inTitle.ID})
+
+	rs = search(goreddit.SearchQuery{Phrases: []string{"generics"}, Thread: "golang"})
+	assertIDs(t, "thread results", searchIDs(rs), []uuid.UUID{inTitle.ID, c.ID})
+
+	rs = search(goreddit.SearchQuery{Phrases: []string{"generics"}, Author: "Bob"})
+	assertIDs(t, "author results", searchIDs(rs), []uuid.UUID{inContent.ID})
+
+	rs, err := s.Search(ctx, goreddit.SearchQuery{Phrases: []string{"generics"}}, 1, 1)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	assertIDs(t, "offset results", searchIDs(rs), []uuid.UUID{c.ID})
+
+	if rs := search(goreddit.SearchQuery{}); len(rs) != 0 {
+		t.Errorf("empty query returned %d results", len(rs))
+	}
+
+	inContent.Content = "Nothing to see here."
+	if err := s.UpdatePost(ctx, &inContent); err != nil {
+		t.Fatalf("UpdatePost: %v", err)
+	}
+	if err := s.DeleteComment(ctx, c.ID); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	rs = search(goreddit.SearchQuery{Phrases: []string{"generics"}})
+	assertIDs(t, "results after changes", searchIDs(rs), []uuid.UUID{inTitle.ID})
+}
+
+func testCascadingDeletes(t *testing.T, s goreddit.Store) {
+	u := createUser(t, s, "alice")
+	th := createThread(t, s, u.ID)
+	p := createPost(t, s, th.ID, u.ID)
+	c := createComment(t, s, p.ID, uuid.Nil, u.ID)
+	reply := createComment(t, s, p.ID, c.ID, u.ID)
+	vote(t, s.VoteComment, reply.ID, u.ID, 1)
+
+	if err := s.DeleteThread(ctx, th.ID); err != nil {
+		t.Fatalf("DeleteThread: %v", err)
+	}
+	if _, err := s.Post(ctx, p.ID); err == nil {
+		t.Error("Post returned a post from a deleted thread")
+	}
+	for _, id := range []uuid.UUID{c.ID, reply.ID} {
+		if _, err := s.Comment(ctx, id); err == nil {
+			t.Errorf("Comment returned comment %v from a deleted thread", id)
+		}
+	}
+	if v, err := s.CommentVote(ctx, reply.ID, u.ID); err != nil || v != 0 {
+		t.Errorf("CommentVote on a deleted comment = %d, %v; want 0", v, err)
+	}
+}
+
+func testErrors(t *testing.T, s goreddit.Store) {
+	u := createUser(t, s, "alice")
+	th := createThread(t, s, u.ID)
+	p := createPost(t, s, th.ID, u.ID)
+	tok := createToken(t, s, u.ID)
+	missing := uuid.New()
+
+	notFound := map[string]error{}
+	_, notFound["Thread"] = s.Thread(ctx, missing)
+	_, notFound["Post"] = s.Post(ctx, missing)
+	_, notFound["Comment"] = s.Comment(ctx, missing)
+	_, notFound["User"] = s.User(ctx, missing)
+	_, notFound["UserByUsername"] = s.UserByUsername(ctx, "bob")
+	_, notFound["UseAPIToken"] = s.UseAPIToken(ctx, []byte("unknown"))
+	notFound["UpdateThread"] = s.UpdateThread(ctx, &goreddit.Thread{ID: missing, Title: "Missing"})
+	notFound["UpdatePost"] = s.UpdatePost(ctx, &goreddit.Post{ID: missing, ThreadID: th.ID, Title: "Missing"})
+	notFound["CreatePost"] = s.CreatePost(ctx, &goreddit.Post{ID: uuid.New(), ThreadID: missing, Title: "Orphan"})
+	notFound["CreateComment"] = s.CreateComment(ctx, &goreddit.Comment{ID: uuid.New(), PostID: missing, Content: "Orphan"})
+	notFound["VotePost"] = s.VotePost(ctx, missing, u.ID, 1)
+	for method, err := range notFound {
+		if !errors.Is(err, goreddit.ErrNotFound) {
+			t.Errorf("%s returned %v, want ErrNotFound", method, err)
+		}
+	}
+
+	bob := createUser(t, s, "bob")
+	bob.Username = "alice"
+	conflict := map[string]error{
+		"CreateThread":   s.CreateThread(ctx, &th),
+		"CreatePost":     s.CreatePost(ctx, &p),
+		"CreateUser":     s.CreateUser(ctx, &goreddit.User{ID: uuid.New(), Username: "alice", Password: "x"}),
+		"UpdateUser":     s.UpdateUser(ctx, &bob),
+		"CreateAPIToken": s.CreateAPIToken(ctx, &goreddit.APIToken{ID: uuid.New(), UserID: u.ID, Name: "copy", Hash: tok.Hash}),
+	}
+	for method, err := range conflict {
+		if !errors.Is(err, goreddit.ErrConflict) {
+			t.Errorf("%s returned %v, want ErrConflict", method, err)
+		}
+	}
+}
+
+func createUser(t *testing.T, s goreddit.Store, username string) goreddit.User {
+	t.Helper()
+	u := goreddit.User{ID: uuid.New(), Username: username, Password: "secret"}
+	if err := s.CreateUser(ctx, &u); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return u
+}
+
+func createThread(t *testing.T, s goreddit.Store, userID uuid.UUID) goreddit.Thread {
+	t.Helper()
+	th := goreddit.Thread{ID: uuid.New(), UserID: userID, Title: "Thread", Description: "A thread"}
+	if err := s.CreateThread(ctx, &th); err != nil {
+		t.Fatalf("CreateThread: %v", err)
+	}
+	return th
+}
+
+func createPost(t *testing.T, s goreddit.Store, threadID, userID uuid.UUID) goreddit.Post {
+	t.Helper()
+	p := goreddit.Post{ID: uuid.New(), ThreadID: threadID, UserID: userID, Title: "Post", Content: "Content"}
+	if err := s.CreatePost(ctx, &p); err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	return p
+}
+
+func createComment(t *testing.T, s goreddit.Store, postID, parentID, userID uuid.UUID) goreddit.Comment {
+	t.Helper()
+	c := goreddit.Comment{ID: uuid.New(), PostID: postID, ParentID: parentID, UserID: userID, Content: "Comment", ContentHTML: "<p>Comment</p>"}
+	if err := s.CreateComment(ctx, &c); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	return c
+}
+
+func createToken(t *testing.T, s goreddit.Store, userID uuid.UUID) goreddit.APIToken {
+	t.Helper()
+	id := uuid.New()
+	tok := goreddit.APIToken{ID: id, UserID: userID, Name: "bot", Hash: id[:]}
+	if err := s.CreateAPIToken(ctx, &tok); err != nil {
+		t.Fatalf("CreateAPIToken: %v", err)
+	}
+	return tok
+}
+
+func vote(t *testing.T, f func(ctx context.Context, targetID, userID uuid.UUID, value int) error, targetID, userID uuid.UUID, value int) {
+	t.Helper()
+	if err := f(ctx, targetID, userID, value); err != nil {
+		t.Fatalf("vote: %v", err)
+	}
+}
+
+func assertIDs(t *testing.T, name string, got, want []uuid.UUID) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s: got %d items %v, want %d %v", name, len(got), got, len(want), want)
+		return
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+			return
+		}
+	}
+}
+
+// maxID and minID order IDs the way listings break ties.
+func maxID(a, b uuid.UUID) uuid.UUID {
+	if compareIDs(a, b) > 0 {
+		return a
+	}
+	return b
+}
+
+func minID(a, b uuid.UUID) uuid.UUID {
+	if compareIDs(a, b) > 0 {
+		return b
+	}
+	return a
+}
+
+func compareIDs(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+func threadIDs(ts []goreddit.Thread) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, t := range ts {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func postIDs(ps []goreddit.Post) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, p := range ps {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func commentIDs(cs []goreddit.Comment) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, c := range cs {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func searchIDs(rs []goreddit.SearchResult) []uuid.UUID {
+	ids := make([]uuid.UUID, len(rs))
+	for i, r := range rs {
+		ids[i] = r.PostID
+		if r.CommentID != uuid.Nil {
+			ids[i] = r.CommentID
+		}
+	}
+	return ids
+}
+
+func tokenIDs(ts []goreddit.APIToken) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, t := range ts {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func modLogIDs(es []goreddit.ModLogEntry) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, e := range es {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+// sortUUIDs sorts ids the way stores order rows by ID.
+func sortUUIDs(ids []uuid.UUID) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+}
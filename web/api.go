@@ -0,0 +1,1363 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/blrobin2/goreddit"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxRequestBody caps the size of JSON request bodies.
+const maxRequestBody = 1 << 20
+
+// APIHandler serves the JSON API. It shares the session cookie with the HTML
+// UI, but only accepts JSON request bodies so that browsers cannot forge
+// writes from other sites without a CORS preflight.
+type APIHandler struct {
+	store    goreddit.Store
+	blobs    goreddit.BlobStore
+	sessions *scs.SessionManager
+}
+
+func (h *APIHandler) Routes(r chi.Router) {
+	r.Route("/threads", func(r chi.Router) {
+		r.Get("/", h.ListThreads())
+		r.Post("/", h.CreateThread())
+		r.Get("/{id}", h.GetThread())
+		r.Put("/{id}", h.UpdateThread())
+		r.Delete("/{id}", h.DeleteThread())
+		r.Get("/{id}/posts", h.ListThreadPosts())
+		r.Post("/{id}/posts", h.CreatePost())
+	})
+	r.Route("/posts", func(r chi.Router) {
+		r.Get("/", h.ListPosts())
+		r.Get("/{id}", h.GetPost())
+		r.Put("/{id}", h.UpdatePost())
+		r.Delete("/{id}", h.DeletePost())
+		r.Get("/{id}/comments", h.ListComments())
+		r.Post("/{id}/comments", h.CreateComment())
+		r.Get("/{id}/vote", h.GetPostVote())
+		r.Put("/{id}/vote", h.VotePost())
+	})
+	r.Route("/comments", func(r chi.Router) {
+		r.Get("/{id}", h.GetComment())
+		r.Put("/{id}", h.UpdateComment())
+		r.Delete("/{id}", h.DeleteComment())
+		r.Get("/{id}/vote", h.GetCommentVote())
+		r.Put("/{id}/vote", h.VoteComment())
+	})
+	r.Route("/users", func(r chi.Router) {
+		r.Post("/", h.CreateUser())
+		r.Get("/me", h.GetCurrentUser())
+		r.Get("/{id}", h.GetUser())
+		r.Put("/{id}", h.UpdateUser())
+		r.Delete("/{id}", h.DeleteUser())
+	})
+	r.Route("/tokens", func(r chi.Router) {
+		r.Get("/", h.ListTokens())
+		r.Post("/", h.CreateToken())
+		r.Delete("/{id}", h.DeleteToken())
+	})
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "Not found.")
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed.")
+	})
+}
+
+type apiThread struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func newAPIThread(t goreddit.Thread) apiThread {
+	return apiThread{
+		ID:          t.ID,
+		Title:       t.Title,
+		Description: t.Description,
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+}
+
+type apiPost struct {
+	ID            uuid.UUID  `json:"id"`
+	ThreadID      uuid.UUID  `json:"thread_id"`
+	ThreadTitle   string     `json:"thread_title,omitempty"`
+	AuthorID      *uuid.UUID `json:"author_id"`
+	Author        string     `json:"author"`
+	Title         string     `json:"title"`
+	Kind          string     `json:"kind"`
+	URL           string     `json:"url,omitempty"`
+	Domain        string     `json:"domain,omitempty"`
+	ImageURL      string     `json:"image_url,omitempty"`
+	ThumbnailURL  string     `json:"thumbnail_url,omitempty"`
+	Content       string     `json:"content"`
+	ContentHTML   string     `json:"content_html"`
+	Votes         int        `json:"votes"`
+	Upvotes       int        `json:"upvotes"`
+	Downvotes     int        `json:"downvotes"`
+	CommentsCount int        `json:"comments_count"`
+	Locked        bool       `json:"locked"`
+	Pinned        bool       `json:"pinned"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	EditedAt      *time.Time `json:"edited_at"`
+}
+
+func newAPIPost(p goreddit.Post) apiPost {
+	return apiPost{
+		ID:            p.ID,
+		ThreadID:      p.ThreadID,
+		ThreadTitle:   p.ThreadTitle,
+		AuthorID:      optionalID(p.UserID),
+		Author:        p.Username,
+		Title:         p.Title,
+		Kind:          string(p.Kind),
+		URL:           p.URL,
+		Domain:        p.Domain,
+		ImageURL:      optionalImageURL(p.Image),
+		ThumbnailURL:  optionalImageURL(p.Thumbnail),
+		Content:       p.Content,
+		ContentHTML:   string(contentHTML(p.ContentHTML, p.Content)),
+		Votes:         p.Votes,
+		Upvotes:       p.Upvotes,
+		Downvotes:     p.Downvotes,
+		CommentsCount: p.CommentsCount,
+		Locked:        p.Locked,
+		Pinned:        p.Pinned,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+		EditedAt:      p.EditedAt,
+	}
+}
+
+type apiComment struct {
+	ID          uuid.UUID  `json:"id"`
+	PostID      uuid.UUID  `json:"post_id"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	AuthorID    *uuid.UUID `json:"author_id"`
+	Author      string     `json:"author"`
+	Content     string     `json:"content"`
+	ContentHTML string     `json:"content_html"`
+	Votes       int        `json:"votes"`
+	Upvotes     int        `json:"upvotes"`
+	Downvotes   int        `json:"downvotes"`
+	Deleted     bool       `json:"deleted"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	EditedAt    *time.Time `json:"edited_at"`
+}
+
+func newAPIComment(c goreddit.Comment) apiComment {
+	return apiComment{
+		ID:          c.ID,
+		PostID:      c.PostID,
+		ParentID:    optionalID(c.ParentID),
+		AuthorID:    optionalID(c.UserID),
+		Author:      c.Username,
+		Content:     c.Content,
+		ContentHTML: string(contentHTML(c.ContentHTML, c.Content)),
+		Votes:       c.Votes,
+		Upvotes:     c.Upvotes,
+		Downvotes:   c.Downvotes,
+		Deleted:     c.Deleted,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+		EditedAt:    c.EditedAt,
+	}
+}
+
+type apiUser struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	PostKarma    int       `json:"post_karma"`
+	CommentKarma int       `json:"comment_karma"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func newAPIUser(u goreddit.User) apiUser {
+	return apiUser{
+		ID:           u.ID,
+		Username:     u.Username,
+		PostKarma:    u.PostKarma,
+		CommentKarma: u.CommentKarma,
+		CreatedAt:    u.CreatedAt,
+	}
+}
+
+type apiToken struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Token      string     `json:"token,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func newAPIToken(t goreddit.APIToken) apiToken {
+	return apiToken{
+		ID:         t.ID,
+		Name:       t.Name,
+		CreatedAt:  t.CreatedAt,
+		LastUsedAt: t.LastUsedAt,
+	}
+}
+
+type apiVote struct {
+	Value int `json:"value"`
+}
+
+type apiList struct {
+	Data   interface{} `json:"data"`
+	Before *uuid.UUID  `json:"before"`
+	After  *uuid.UUID  `json:"after"`
+}
+
+func newAPIList(data interface{}, nav Pagination) apiList {
+	l := apiList{Data: data}
+	if nav.HasPrev {
+		l.Before = optionalID(nav.first)
+	}
+	if nav.HasNext {
+		l.After = optionalID(nav.last)
+	}
+	return l
+}
+
+type apiError struct {
+	Error apiErrorBody `json:"error"`
+}
+
+type apiErrorBody struct {
+	Status  int        `json:"status"`
+	Message string     `json:"message"`
+	Fields  FormErrors `json:"fields,omitempty"`
+}
+
+func (h *APIHandler) ListThreads() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, err := pageRequest(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		ts, err := h.store.Threads(r.Context(), page)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		start, end, nav := paginate(r, page, len(ts), func(i int) uuid.UUID { return ts[i].ID })
+		data := []apiThread{}
+		for _, t := range ts[start:end] {
+			data = append(data, newAPIThread(t))
+		}
+
+		writeJSON(w, http.StatusOK, newAPIList(data, nav))
+	}
+}
+
+func (h *APIHandler) GetThread() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAPIThread(t))
+	}
+}
+
+func (h *APIHandler) CreateThread() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+		if !hasKarma(user, MinKarma.CreateThread) {
+			writeError(w, http.StatusForbidden, notEnoughKarma(MinKarma.CreateThread, "create threads"))
+			return
+		}
+		msg, err := shutOut(r.Context(), h.store, user, uuid.Nil)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if msg != "" {
+			writeError(w, http.StatusForbidden, msg)
+			return
+		}
+
+		var form CreateThreadForm
+		if !decodeJSON(w, r, &form) {
+			return
+		}
+		if !form.Validate() {
+			writeFormErrors(w, form.Errors)
+			return
+		}
+
+		t := &goreddit.Thread{
+			ID:          uuid.New(),
+			UserID:      user.ID,
+			Title:       form.Title,
+			Description: form.Description,
+		}
+		if err := h.store.CreateThread(r.Context(), t); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, newAPIThread(*t))
+	}
+}
+
+func (h *APIHandler) UpdateThread() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		ok, err = canEditThread(r.Context(), h.store, user, t)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if !ok {
+			writeError(w, http.StatusForbidden, "You are not allowed to do that.")
+			return
+		}
+
+		var form CreateThreadForm
+		if !decodeJSON(w, r, &form) {
+			return
+		}
+		if !form.Validate() {
+			writeFormErrors(w, form.Errors)
+			return
+		}
+
+		t.Title = form.Title
+		t.Description = form.Description
+		if err := updateThread(r.Context(), h.store, user, &t, modReason(r)); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAPIThread(t))
+	}
+}
+
+func (h *APIHandler) DeleteThread() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		if !canModify(user, t.UserID) {
+			writeError(w, http.StatusForbidden, "You are not allowed to do that.")
+			return
+		}
+
+		if err := deleteThread(r.Context(), h.store, h.blobs, id); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *APIHandler) ListPosts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l, _ := postListing(r)
+		page, err := pageRequest(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		ps, err := h.store.Posts(r.Context(), l, page)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writePosts(w, r, page, ps)
+	}
+}
+
+func (h *APIHandler) ListThreadPosts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		l, _ := postListing(r)
+		page, err := pageRequest(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		ps, err := h.store.PostsByThead(r.Context(), id, l, page)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writePosts(w, r, page, ps)
+	}
+}
+
+func writePosts(w http.ResponseWriter, r *http.Request, page goreddit.Page, ps []goreddit.Post) {
+	start, end, nav := paginate(r, page, len(ps), func(i int) uuid.UUID { return ps[i].ID })
+	data := []apiPost{}
+	for _, p := range ps[start:end] {
+		data = append(data, newAPIPost(p))
+	}
+
+	writeJSON(w, http.StatusOK, newAPIList(data, nav))
+}
+
+func (h *APIHandler) GetPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), id)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAPIPost(p))
+	}
+}
+
+func (h *APIHandler) CreatePost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+		if !hasKarma(user, MinKarma.CreatePost) {
+			writeError(w, http.StatusForbidden, notEnoughKarma(MinKarma.CreatePost, "submit posts"))
+			return
+		}
+
+		threadID, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		msg, err := shutOut(r.Context(), h.store, user, threadID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if msg != "" {
+			writeError(w, http.StatusForbidden, msg)
+			return
+		}
+
+		var form CreatePostForm
+		if !decodeJSON(w, r, &form) {
+			return
+		}
+		if !form.Validate() {
+			writeFormErrors(w, form.Errors)
+			return
+		}
+		if form.Kind == string(goreddit.PostImage) {
+			writeFormErrors(w, FormErrors{"Kind": "Image posts can only be submitted from the website."})
+			return
+		}
+		if form.Kind == string(goreddit.PostLink) {
+			dup, err := h.store.PostByURL(r.Context(), threadID, form.URL)
+			if err == nil {
+				writeError(w, http.StatusConflict, "That link has already been posted in this thread as post "+dup.ID.String()+".")
+				return
+			}
+			if !errors.Is(err, goreddit.ErrNotFound) {
+				writeStoreError(w, err)
+				return
+			}
+		}
+
+		p := &goreddit.Post{
+			ID:          uuid.New(),
+			ThreadID:    threadID,
+			UserID:      user.ID,
+			Title:       form.Title,
+			Content:     form.Content,
+			ContentHTML: renderMarkdown(form.Content),
+			Kind:        goreddit.PostKind(form.Kind),
+			URL:         form.URL,
+			Domain:      linkDomain(form.URL),
+		}
+		if err := h.store.CreatePost(r.Context(), p); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		p.Username = user.Username
+
+		writeJSON(w, http.StatusCreated, newAPIPost(*p))
+	}
+}
+
+func (h *APIHandler) UpdatePost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), id)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		if !canModify(user, p.UserID) {
+			writeError(w, http.StatusForbidden, "You are not allowed to do that.")
+			return
+		}
+
+		var form CreatePostForm
+		if !decodeJSON(w, r, &form) {
+			return
+		}
+		form.Kind, form.URL = string(p.Kind), p.URL
+		if !form.Validate() {
+			writeFormErrors(w, form.Errors)
+			return
+		}
+
+		now := time.Now()
+		p.Title = form.Title
+		p.Content = form.Content
+		p.ContentHTML = renderMarkdown(form.Content)
+		p.EditedAt = &now
+		if err := h.store.UpdatePost(r.Context(), &p); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAPIPost(p))
+	}
+}
+
+func (h *APIHandler) DeletePost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), id)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		ok, err = canRemove(r.Context(), h.store, user, p.UserID, p.ThreadID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if !ok {
+			writeError(w, http.StatusForbidden, "You are not allowed to do that.")
+			return
+		}
+
+		if err := h.store.DeletePost(r.Context(), id); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		deleteImages(r.Context(), h.blobs, p)
+		if p.UserID != user.ID {
+			if err := logModAction(r.Context(), h.store, user, postEntry(goreddit.ModRemovePost, p, modReason(r))); err != nil {
+				writeStoreError(w, err)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *APIHandler) ListComments() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		postID, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		page, err := pageRequest(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		cs, err := h.store.CommentsByPost(r.Context(), postID, page)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		start, end, nav := paginate(r, page, len(cs), func(i int) uuid.UUID { return cs[i].ID })
+		data := []apiComment{}
+		for _, c := range cs[start:end] {
+			data = append(data, newAPIComment(c))
+		}
+
+		writeJSON(w, http.StatusOK, newAPIList(data, nav))
+	}
+}
+
+func (h *APIHandler) GetComment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAPIComment(c))
+	}
+}
+
+func (h *APIHandler) CreateComment() http.HandlerFunc {
+	type request struct {
+		CreateCommentForm
+		ParentID *uuid.UUID `json:"parent_id"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+		if !hasKarma(user, MinKarma.CreateComment) {
+			writeError(w, http.StatusForbidden, notEnoughKarma(MinKarma.CreateComment, "comment"))
+			return
+		}
+
+		postID, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), postID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		msg, err := shutOut(r.Context(), h.store, user, p.ThreadID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if msg != "" {
+			writeError(w, http.StatusForbidden, msg)
+			return
+		}
+
+		locked, err := lockedFor(r.Context(), h.store, user, p)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if locked {
+			writeError(w, http.StatusForbidden, "This post is locked, so it takes no new comments.")
+			return
+		}
+
+		var req request
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if !req.Validate() {
+			writeFormErrors(w, req.Errors)
+			return
+		}
+
+		c := &goreddit.Comment{
+			ID:          uuid.New(),
+			PostID:      postID,
+			UserID:      user.ID,
+			Content:     req.Content,
+			ContentHTML: renderMarkdown(req.Content),
+		}
+		if req.ParentID != nil {
+			parent, err := h.store.Comment(r.Context(), *req.ParentID)
+			if errors.Is(err, goreddit.ErrNotFound) {
+				writeError(w, http.StatusUnprocessableEntity, "Parent comment does not exist.")
+				return
+			}
+			if err != nil {
+				writeStoreError(w, err)
+				return
+			}
+			if parent.PostID != postID {
+				writeError(w, http.StatusUnprocessableEntity, "Parent comment belongs to another post.")
+				return
+			}
+			c.ParentID = parent.ID
+		}
+
+		if err := h.store.CreateComment(r.Context(), c); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		c.Username = user.Username
+
+		writeJSON(w, http.StatusCreated, newAPIComment(*c))
+	}
+}
+
+func (h *APIHandler) UpdateComment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		if c.Deleted || !canModify(user, c.UserID) {
+			writeError(w, http.StatusForbidden, "You are not allowed to do that.")
+			return
+		}
+
+		var form CreateCommentForm
+		if !decodeJSON(w, r, &form) {
+			return
+		}
+		if !form.Validate() {
+			writeFormErrors(w, form.Errors)
+			return
+		}
+
+		now := time.Now()
+		c.Content = form.Content
+		c.ContentHTML = renderMarkdown(form.Content)
+		c.EditedAt = &now
+		if err := h.store.UpdateComment(r.Context(), &c); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAPIComment(c))
+	}
+}
+
+func (h *APIHandler) DeleteComment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		ok, err = canRemove(r.Context(), h.store, user, c.UserID, p.ThreadID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if !ok {
+			writeError(w, http.StatusForbidden, "You are not allowed to do that.")
+			return
+		}
+
+		if err := h.store.DeleteComment(r.Context(), id); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if c.UserID != user.ID {
+			if err := logModAction(r.Context(), h.store, user, commentEntry(goreddit.ModRemoveComment, c, p, modReason(r))); err != nil {
+				writeStoreError(w, err)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *APIHandler) GetPostVote() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		v, err := h.store.PostVote(r.Context(), id, user.ID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, apiVote{Value: v})
+	}
+}
+
+func (h *APIHandler) VotePost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var v apiVote
+		if !decodeJSON(w, r, &v) || !validVote(w, v) {
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), id)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		msg, err := shutOut(r.Context(), h.store, user, p.ThreadID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if msg != "" {
+			writeError(w, http.StatusForbidden, msg)
+			return
+		}
+
+		locked, err := lockedFor(r.Context(), h.store, user, p)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if locked {
+			writeError(w, http.StatusForbidden, "This post is locked, so it takes no new votes.")
+			return
+		}
+
+		if err := h.store.VotePost(r.Context(), id, user.ID, v.Value); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, v)
+	}
+}
+
+func (h *APIHandler) GetCommentVote() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		v, err := h.store.CommentVote(r.Context(), id, user.ID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, apiVote{Value: v})
+	}
+}
+
+func (h *APIHandler) VoteComment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var v apiVote
+		if !decodeJSON(w, r, &v) || !validVote(w, v) {
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		msg, err := shutOut(r.Context(), h.store, user, p.ThreadID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if msg != "" {
+			writeError(w, http.StatusForbidden, msg)
+			return
+		}
+
+		locked, err := lockedFor(r.Context(), h.store, user, p)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if locked {
+			writeError(w, http.StatusForbidden, "This post is locked, so it takes no new votes.")
+			return
+		}
+
+		if err := h.store.VoteComment(r.Context(), id, user.ID, v.Value); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, v)
+	}
+}
+
+func validVote(w http.ResponseWriter, v apiVote) bool {
+	if v.Value < -1 || v.Value > 1 {
+		writeFormErrors(w, FormErrors{"Value": "Vote must be -1, 0 or 1."})
+		return false
+	}
+
+	return true
+}
+
+func (h *APIHandler) GetCurrentUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAPIUser(user))
+	}
+}
+
+func (h *APIHandler) GetUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		u, err := h.store.User(r.Context(), id)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAPIUser(u))
+	}
+}
+
+func (h *APIHandler) CreateUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var form RegisterUserForm
+		if !decodeJSON(w, r, &form) {
+			return
+		}
+		if _, err := h.store.UserByUsername(r.Context(), form.Username); err == nil {
+			form.UsernameTaken = true
+		}
+		if !form.Validate() {
+			writeFormErrors(w, form.Errors)
+			return
+		}
+
+		password, err := bcrypt.GenerateFromPassword([]byte(form.Password), bcrypt.DefaultCost)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		u := &goreddit.User{
+			ID:       uuid.New(),
+			Username: form.Username,
+			Password: string(password),
+		}
+		if err := h.store.CreateUser(r.Context(), u); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, newAPIUser(*u))
+	}
+}
+
+func (h *APIHandler) UpdateUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if id != user.ID {
+			writeError(w, http.StatusForbidden, "You can only change your own account.")
+			return
+		}
+
+		var form RegisterUserForm
+		if !decodeJSON(w, r, &form) {
+			return
+		}
+		if u, err := h.store.UserByUsername(r.Context(), form.Username); err == nil && u.ID != user.ID {
+			form.UsernameTaken = true
+		}
+		if !form.Validate() {
+			writeFormErrors(w, form.Errors)
+			return
+		}
+
+		password, err := bcrypt.GenerateFromPassword([]byte(form.Password), bcrypt.DefaultCost)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		user.Username = form.Username
+		user.Password = string(password)
+		if err := h.store.UpdateUser(r.Context(), &user); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAPIUser(user))
+	}
+}
+
+func (h *APIHandler) DeleteUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if id != user.ID {
+			writeError(w, http.StatusForbidden, "You can only delete your own account.")
+			return
+		}
+
+		if err := h.store.DeleteUser(r.Context(), id); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		h.sessions.Remove(r.Context(), "user_id")
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *APIHandler) ListTokens() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+
+		ts, err := h.store.APITokens(r.Context(), user.ID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		data := []apiToken{}
+		for _, t := range ts {
+			data = append(data, newAPIToken(t))
+		}
+
+		writeJSON(w, http.StatusOK, apiList{Data: data})
+	}
+}
+
+func (h *APIHandler) CreateToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+
+		var form CreateTokenForm
+		if !decodeJSON(w, r, &form) {
+			return
+		}
+		if !form.Validate() {
+			writeFormErrors(w, form.Errors)
+			return
+		}
+
+		plain, t, err := generateAPIToken(user.ID, form.Name)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if err := h.store.CreateAPIToken(r.Context(), t); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		res := newAPIToken(*t)
+		res.Token = plain
+		writeJSON(w, http.StatusCreated, res)
+	}
+}
+
+func (h *APIHandler) DeleteToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireAPIUser(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := getId(r, "id")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if status, err := revokeAPIToken(r.Context(), h.store, user, id); err != nil {
+			writeError(w, status, err.Error())
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func requireAPIUser(w http.ResponseWriter, r *http.Request) (goreddit.User, bool) {
+	user, ok := r.Context().Value(KeyUserID).(goreddit.User)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "You must be logged in.")
+	}
+
+	return user, ok
+}
+
+// decodeJSON decodes the request body into v, writing an error response and
+// returning false if the body is not JSON.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, "Request body must be application/json.")
+		return false
+	}
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return false
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, apiError{Error: apiErrorBody{
+		Status:  status,
+		Message: message,
+	}})
+}
+
+// writeStoreError responds to a failed store call with 404 for missing rows,
+// 409 for conflicting writes and 500 for anything else.
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, goreddit.ErrNotFound):
+		writeError(w, http.StatusNotFound, "Not found.")
+	case errors.Is(err, goreddit.ErrConflict):
+		writeError(w, http.StatusConflict, "Conflicts with an existing resource.")
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// writeFormErrors reports validation errors keyed by the JSON field names
+// rather than the form's Go field names.
+func writeFormErrors(w http.ResponseWriter, errs FormErrors) {
+	fields := FormErrors{}
+	for k, v := range errs {
+		fields[snakeCase(k)] = v
+	}
+
+	writeJSON(w, http.StatusUnprocessableEntity, apiError{Error: apiErrorBody{
+		Status:  http.StatusUnprocessableEntity,
+		Message: "Validation failed.",
+		Fields:  fields,
+	}})
+}
+
+// snakeCase turns a form field name into the name used in the API. Runs of
+// capitals are initialisms, so "URL" becomes "url" and "NewURL" "new_url".
+func snakeCase(s string) string {
+	var b strings.Builder
+	rs := []rune(s)
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			if i > 0 && (!unicode.IsUpper(rs[i-1]) || (i+1 < len(rs) && unicode.IsLower(rs[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+func optionalImageURL(key string) string {
+	if key == "" {
+		return ""
+	}
+
+	return imageURL(key)
+}
+
+func optionalID(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+
+	return &id
+}
@@ -0,0 +1,282 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+// apiClient makes requests to the JSON API as the owner of token, or
+// anonymously if it is empty.
+type apiClient struct {
+	t     *testing.T
+	h     http.Handler
+	token string
+}
+
+func (c apiClient) do(method, path string, body interface{}) *httptest.ResponseRecorder {
+	c.t.Helper()
+	var r *http.Request
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		r = httptest.NewRequest(method, "/api/v1"+path, bytes.NewReader(b))
+		r.Header.Set("Content-Type", "application/json")
+	} else {
+		r = httptest.NewRequest(method, "/api/v1"+path, nil)
+	}
+	if c.token != "" {
+		r.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	w := httptest.NewRecorder()
+	c.h.ServeHTTP(w, r)
+	return w
+}
+
+// decode decodes a response body into v, failing the test unless the
+// response has the given status.
+func decode(t *testing.T, w *httptest.ResponseRecorder, status int, v interface{}) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d: %s", w.Code, status, w.Body)
+	}
+	if v == nil {
+		return
+	}
+	if err := json.NewDecoder(w.Body).Decode(v); err != nil {
+		t.Fatalf("decoding %s: %v", w.Body, err)
+	}
+}
+
+// wantError checks that a response is an API error with the given status.
+func wantError(t *testing.T, w *httptest.ResponseRecorder, status int) apiErrorBody {
+	t.Helper()
+	var e apiError
+	decode(t, w, status, &e)
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want JSON", ct)
+	}
+	if e.Error.Status != status || e.Error.Message == "" {
+		t.Errorf("error body = %+v, want status %d and a message", e.Error, status)
+	}
+
+	return e.Error
+}
+
+// newAPIUserClient creates a user with an API token and returns a client for
+// them.
+func newAPIUserClient(t *testing.T, h http.Handler, store goreddit.Store, username string) (goreddit.User, apiClient) {
+	t.Helper()
+	u := goreddit.User{ID: uuid.New(), Username: username, Password: "secret"}
+	if err := store.CreateUser(context.Background(), &u); err != nil {
+		t.Fatal(err)
+	}
+	plain, token, err := generateAPIToken(u.ID, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateAPIToken(context.Background(), token); err != nil {
+		t.Fatal(err)
+	}
+
+	return u, apiClient{t: t, h: h, token: plain}
+}
+
+func TestAPIAuth(t *testing.T) {
+	h, store, _ := newTestHandler(t)
+	alice, client := newAPIUserClient(t, h, store, "alice")
+
+	var me apiUser
+	decode(t, client.do(http.MethodGet, "/users/me", nil), http.StatusOK, &me)
+	if me.ID != alice.ID || me.Username != "alice" {
+		t.Errorf("GET /users/me = %+v, want alice", me)
+	}
+
+	anonymous := apiClient{t: t, h: h}
+	wantError(t, anonymous.do(http.MethodGet, "/users/me", nil), http.StatusUnauthorized)
+	wantError(t, anonymous.do(http.MethodPost, "/threads", CreateThreadForm{Title: "Go", Description: "Go"}), http.StatusUnauthorized)
+	wantError(t, apiClient{t: t, h: h, token: "grd_nope"}.do(http.MethodGet, "/users/me", nil), http.StatusUnauthorized)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
+	r.Header.Set("Authorization", "Basic YWxpY2U6c2VjcmV0")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	wantError(t, w, http.StatusUnauthorized)
+
+	// Token requests need no CSRF token.
+	var th apiThread
+	decode(t, client.do(http.MethodPost, "/threads", CreateThreadForm{Title: "Go", Description: "All things Go"}), http.StatusCreated, &th)
+	if th.Title != "Go" {
+		t.Errorf("POST /threads = %+v", th)
+	}
+
+	if err := store.DeleteUser(context.Background(), alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	wantError(t, client.do(http.MethodGet, "/users/me", nil), http.StatusUnauthorized)
+}
+
+func TestAPIErrors(t *testing.T) {
+	h, store, _ := newTestHandler(t)
+	_, client := newAPIUserClient(t, h, store, "alice")
+
+	wantError(t, client.do(http.MethodGet, "/threads/"+uuid.NewString(), nil), http.StatusNotFound)
+	wantError(t, client.do(http.MethodGet, "/nowhere", nil), http.StatusNotFound)
+	wantError(t, client.do(http.MethodGet, "/threads/not-a-uuid", nil), http.StatusBadRequest)
+	wantError(t, client.do(http.MethodGet, "/threads?limit=1000", nil), http.StatusBadRequest)
+	wantError(t, client.do(http.MethodPatch, "/threads", nil), http.StatusMethodNotAllowed)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/threads", bytes.NewReader([]byte("title=Go")))
+	r.Header.Set("Authorization", "Bearer "+client.token)
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	wantError(t, w, http.StatusUnsupportedMediaType)
+
+	e := wantError(t, client.do(http.MethodPost, "/threads", CreateThreadForm{}), http.StatusUnprocessableEntity)
+	if e.Fields["title"] == "" || e.Fields["description"] == "" {
+		t.Errorf("fields = %v, want title and description", e.Fields)
+	}
+	e = wantError(t, apiClient{t: t, h: h}.do(http.MethodPost, "/users", RegisterUserForm{Username: "alice", Password: "password1", PasswordConfirm: "password2"}), http.StatusUnprocessableEntity)
+	if e.Fields["username"] == "" || e.Fields["password_confirm"] == "" {
+		t.Errorf("fields = %v, want username and password_confirm", e.Fields)
+	}
+
+	var th apiThread
+	decode(t, client.do(http.MethodPost, "/threads", CreateThreadForm{Title: "Go", Description: "Go"}), http.StatusCreated, &th)
+	link := CreatePostForm{Title: "Go", Kind: "link", URL: "https://go.dev"}
+	decode(t, client.do(http.MethodPost, "/threads/"+th.ID.String()+"/posts", link), http.StatusCreated, nil)
+	link.URL = "HTTPS://go.dev/#top"
+	wantError(t, client.do(http.MethodPost, "/threads/"+th.ID.String()+"/posts", link), http.StatusConflict)
+	e = wantError(t, client.do(http.MethodPost, "/threads/"+th.ID.String()+"/posts", CreatePostForm{Title: "Go", Kind: "link", URL: "ftp://go.dev"}), http.StatusUnprocessableEntity)
+	if e.Fields["url"] == "" {
+		t.Errorf("fields = %v, want url", e.Fields)
+	}
+}
+
+func TestAPIOwnership(t *testing.T) {
+	h, store, _ := newTestHandler(t)
+	alice, aliceClient := newAPIUserClient(t, h, store, "alice")
+	_, bobClient := newAPIUserClient(t, h, store, "bob")
+
+	var th apiThread
+	decode(t, aliceClient.do(http.MethodPost, "/threads", CreateThreadForm{Title: "Go", Description: "Go"}), http.StatusCreated, &th)
+	var p apiPost
+	decode(t, aliceClient.do(http.MethodPost, "/threads/"+th.ID.String()+"/posts", CreatePostForm{Title: "Hello", Content: "World"}), http.StatusCreated, &p)
+	var c apiComment
+	decode(t, aliceClient.do(http.MethodPost, "/posts/"+p.ID.String()+"/comments", CreateCommentForm{Content: "First"}), http.StatusCreated, &c)
+
+	forbidden := []struct {
+		method, path string
+		body         interface{}
+	}{
+		{http.MethodPut, "/threads/" + th.ID.String(), CreateThreadForm{Title: "Mine", Description: "Mine"}},
+		{http.MethodDelete, "/threads/" + th.ID.String(), nil},
+		{http.MethodPut, "/posts/" + p.ID.String(), CreatePostForm{Title: "Mine", Content: "Mine"}},
+		{http.MethodDelete, "/posts/" + p.ID.String(), nil},
+		{http.MethodPut, "/comments/" + c.ID.String(), CreateCommentForm{Content: "Mine"}},
+		{http.MethodDelete, "/comments/" + c.ID.String(), nil},
+		{http.MethodPut, "/users/" + alice.ID.String(), RegisterUserForm{Username: "mallory", Password: "password1", PasswordConfirm: "password1"}},
+		{http.MethodDelete, "/users/" + alice.ID.String(), nil},
+	}
+	for _, tt := range forbidden {
+		w := bobClient.do(tt.method, tt.path, tt.body)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("bob %s %s = %d, want %d", tt.method, tt.path, w.Code, http.StatusForbidden)
+			continue
+		}
+		wantError(t, w, http.StatusForbidden)
+	}
+
+	var got apiPost
+	decode(t, aliceClient.do(http.MethodGet, "/posts/"+p.ID.String(), nil), http.StatusOK, &got)
+	if got.Title != "Hello" || got.Content != "World" {
+		t.Errorf("post after bob's edits = %+v, want it unchanged", got)
+	}
+
+	decode(t, aliceClient.do(http.MethodPut, "/posts/"+p.ID.String(), CreatePostForm{Title: "Hello", Content: "*World*"}), http.StatusOK, &got)
+	if got.Content != "*World*" || got.EditedAt == nil {
+		t.Errorf("post after alice's edit = %+v", got)
+	}
+	decode(t, aliceClient.do(http.MethodDelete, "/comments/"+c.ID.String(), nil), http.StatusNoContent, nil)
+	decode(t, aliceClient.do(http.MethodDelete, "/threads/"+th.ID.String(), nil), http.StatusNoContent, nil)
+	wantError(t, aliceClient.do(http.MethodGet, "/posts/"+p.ID.String(), nil), http.StatusNotFound)
+}
+
+func TestAPIPagination(t *testing.T) {
+	h, store, _ := newTestHandler(t)
+	_, client := newAPIUserClient(t, h, store, "alice")
+	for i := 0; i < 5; i++ {
+		decode(t, client.do(http.MethodPost, "/threads", CreateThreadForm{Title: "Thread", Description: "A thread"}), http.StatusCreated, nil)
+	}
+
+	type page struct {
+		Data   []apiThread `json:"data"`
+		Before *uuid.UUID  `json:"before"`
+		After  *uuid.UUID  `json:"after"`
+	}
+	get := func(query string) page {
+		t.Helper()
+		var p page
+		decode(t, client.do(http.MethodGet, "/threads?limit=2"+query, nil), http.StatusOK, &p)
+		return p
+	}
+
+	first := get("")
+	if len(first.Data) != 2 || first.Before != nil || first.After == nil || *first.After != first.Data[1].ID {
+		t.Fatalf("first page = %+v", first)
+	}
+	second := get("&after=" + first.After.String())
+	if len(second.Data) != 2 || second.Before == nil || *second.Before != second.Data[0].ID || second.After == nil {
+		t.Fatalf("second page = %+v", second)
+	}
+	last := get("&after=" + second.After.String())
+	if len(last.Data) != 1 || last.Before == nil || last.After != nil {
+		t.Fatalf("last page = %+v", last)
+	}
+
+	seen := map[uuid.UUID]bool{}
+	for _, p := range []page{first, second, last} {
+		for _, th := range p.Data {
+			seen[th.ID] = true
+		}
+	}
+	if len(seen) != 5 {
+		t.Errorf("pages held %d threads, want all 5", len(seen))
+	}
+
+	back := get("&before=" + last.Before.String())
+	if len(back.Data) != 2 || back.Data[0].ID != second.Data[0].ID || back.Data[1].ID != second.Data[1].ID {
+		t.Errorf("page before the last = %+v, want %+v", back.Data, second.Data)
+	}
+	if back = get("&before=" + second.Before.String()); len(back.Data) != 2 || back.Before != nil || back.Data[0].ID != first.Data[0].ID {
+		t.Errorf("page before the second = %+v, want the first page", back)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"Title":           "title",
+		"Content":         "content",
+		"URL":             "url",
+		"NewURL":          "new_url",
+		"URLPath":         "url_path",
+		"PasswordConfirm": "password_confirm",
+		"ThreadID":        "thread_id",
+	}
+
+	for name, want := range tests {
+		if got := snakeCase(name); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
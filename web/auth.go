@@ -0,0 +1,132 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+// errorPages renders error responses as full HTML pages.
+type errorPages struct {
+	sessions *scs.SessionManager
+	templ    *template.Template
+}
+
+func newErrorPages(sessions *scs.SessionManager) *errorPages {
+	return &errorPages{
+		sessions: sessions,
+		templ:    template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/error.html")),
+	}
+}
+
+func (p *errorPages) render(w http.ResponseWriter, r *http.Request, status int, message string) {
+	type data struct {
+		SessionData
+		Status  int
+		Title   string
+		Message string
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	p.templ.Execute(w, data{
+		SessionData: GetSessionData(p.sessions, r.Context()),
+		Status:      status,
+		Title:       http.StatusText(status),
+		Message:     message,
+	})
+}
+
+func (p *errorPages) unauthorized(w http.ResponseWriter, r *http.Request) {
+	p.render(w, r, http.StatusUnauthorized, "You must be logged in to do that.")
+}
+
+func (p *errorPages) forbidden(w http.ResponseWriter, r *http.Request) {
+	p.render(w, r, http.StatusForbidden, "You are not allowed to do that.")
+}
+
+func (p *errorPages) notFound(w http.ResponseWriter, r *http.Request) {
+	p.render(w, r, http.StatusNotFound, "We couldn't find what you were looking for. It may have been deleted.")
+}
+
+func (p *errorPages) conflict(w http.ResponseWriter, r *http.Request) {
+	p.render(w, r, http.StatusConflict, "That clashes with something that already exists.")
+}
+
+// storeError responds to a failed store call: a 404 page for missing
+// content, a 409 page for conflicting writes and a 500 for anything else.
+func (p *errorPages) storeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, goreddit.ErrNotFound):
+		p.notFound(w, r)
+	case errors.Is(err, goreddit.ErrConflict):
+		p.conflict(w, r)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// requireLogin only lets logged in users through.
+func (p *errorPages) requireLogin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(KeyUserID).(goreddit.User); !ok {
+			p.unauthorized(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// canModerate reports whether user may moderate the thread: remove its posts
+// and comments, lock and pin posts and appoint moderators. Admins moderate
+// every thread.
+func canModerate(ctx context.Context, store goreddit.Store, user goreddit.User, threadID uuid.UUID) (bool, error) {
+	if user.IsAdmin() {
+		return true, nil
+	}
+	if user.ID == uuid.Nil {
+		return false, nil
+	}
+
+	return store.IsModerator(ctx, threadID, user.ID)
+}
+
+// canRemove reports whether user may delete content in a thread: its author,
+// the thread's moderators and admins may.
+func canRemove(ctx context.Context, store goreddit.Store, user goreddit.User, ownerID, threadID uuid.UUID) (bool, error) {
+	if canModify(user, ownerID) {
+		return true, nil
+	}
+
+	return canModerate(ctx, store, user, threadID)
+}
+
+// canEditThread reports whether user may change a thread's title and
+// description: its creator, its moderators and admins may.
+func canEditThread(ctx context.Context, store goreddit.Store, user goreddit.User, t goreddit.Thread) (bool, error) {
+	return canRemove(ctx, store, user, t.UserID, t.ID)
+}
+
+// lockedFor reports whether post p is locked to user. Locked posts take no
+// new comments or votes, except from the thread's moderators.
+func lockedFor(ctx context.Context, store goreddit.Store, user goreddit.User, p goreddit.Post) (bool, error) {
+	if !p.Locked {
+		return false, nil
+	}
+
+	mod, err := canModerate(ctx, store, user, p.ThreadID)
+	return !mod, err
+}
+
+// canModify reports whether user may edit or delete content owned by
+// ownerID. Content whose author has been deleted can only be managed by
+// admins.
+func canModify(user goreddit.User, ownerID uuid.UUID) bool {
+	return user.IsAdmin() || (ownerID != uuid.Nil && ownerID == user.ID)
+}
@@ -0,0 +1,168 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+// form posts a form to the website as the client's user. API tokens
+// authenticate web requests too, without needing a CSRF token.
+func (c apiClient) form(path string, values url.Values) *httptest.ResponseRecorder {
+	c.t.Helper()
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(values.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("Authorization", "Bearer "+c.token)
+
+	w := httptest.NewRecorder()
+	c.h.ServeHTTP(w, r)
+	return w
+}
+
+func TestLockedPosts(t *testing.T) {
+	h, store, _ := newTestHandler(t)
+	_, alice := newAPIUserClient(t, h, store, "alice")
+	_, bob := newAPIUserClient(t, h, store, "bob")
+
+	var th apiThread
+	decode(t, alice.do(http.MethodPost, "/threads", CreateThreadForm{Title: "Go", Description: "Go"}), http.StatusCreated, &th)
+	var p apiPost
+	decode(t, bob.do(http.MethodPost, "/threads/"+th.ID.String()+"/posts", CreatePostForm{Title: "Heated", Content: "Discussion"}), http.StatusCreated, &p)
+	var c apiComment
+	decode(t, bob.do(http.MethodPost, "/posts/"+p.ID.String()+"/comments", CreateCommentForm{Content: "First"}), http.StatusCreated, &c)
+
+	post := "/posts/" + p.ID.String()
+	if w := bob.form(post+"/lock", nil); w.Code != http.StatusForbidden {
+		t.Errorf("bob locking the post = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if w := alice.form(post+"/lock", nil); w.Code != http.StatusFound {
+		t.Fatalf("alice locking the post = %d, want %d", w.Code, http.StatusFound)
+	}
+	var got apiPost
+	decode(t, bob.do(http.MethodGet, post, nil), http.StatusOK, &got)
+	if !got.Locked {
+		t.Fatalf("post after locking = %+v, want it locked", got)
+	}
+
+	// Only the thread's moderators may comment or vote on a locked post,
+	// whether on the website or through the API.
+	wantError(t, bob.do(http.MethodPost, post+"/comments", CreateCommentForm{Content: "Second"}), http.StatusForbidden)
+	wantError(t, bob.do(http.MethodPut, post+"/vote", apiVote{Value: 1}), http.StatusForbidden)
+	wantError(t, bob.do(http.MethodPut, "/comments/"+c.ID.String()+"/vote", apiVote{Value: 1}), http.StatusForbidden)
+	web := []struct {
+		path   string
+		values url.Values
+	}{
+		{post, url.Values{"content": {"Second"}}},
+		{post, url.Values{"content": {"Reply"}, "parent_id": {c.ID.String()}}},
+		{post + "/upvote", nil},
+		{"/comments/" + c.ID.String() + "/downvote", nil},
+	}
+	for _, tt := range web {
+		if w := bob.form(tt.path, tt.values); w.Code != http.StatusForbidden {
+			t.Errorf("bob POST %s %v = %d, want %d", tt.path, tt.values, w.Code, http.StatusForbidden)
+		}
+	}
+
+	decode(t, alice.do(http.MethodPost, post+"/comments", CreateCommentForm{Content: "Locked for now."}), http.StatusCreated, nil)
+	decode(t, alice.do(http.MethodPut, post+"/vote", apiVote{Value: 1}), http.StatusOK, nil)
+	if w := alice.form(post, url.Values{"content": {"Please keep it civil."}}); w.Code != http.StatusFound {
+		t.Errorf("alice commenting on the website = %d, want %d", w.Code, http.StatusFound)
+	}
+
+	if w := alice.form(post+"/unlock", nil); w.Code != http.StatusFound {
+		t.Fatalf("alice unlocking the post = %d, want %d", w.Code, http.StatusFound)
+	}
+	decode(t, bob.do(http.MethodPost, post+"/comments", CreateCommentForm{Content: "Second"}), http.StatusCreated, nil)
+}
+
+func TestPinnedPosts(t *testing.T) {
+	h, store, _ := newTestHandler(t)
+	_, alice := newAPIUserClient(t, h, store, "alice")
+
+	var th apiThread
+	decode(t, alice.do(http.MethodPost, "/threads", CreateThreadForm{Title: "Go", Description: "Go"}), http.StatusCreated, &th)
+	var announcement, other apiPost
+	decode(t, alice.do(http.MethodPost, "/threads/"+th.ID.String()+"/posts", CreatePostForm{Title: "Announcement", Content: "Read me"}), http.StatusCreated, &announcement)
+	decode(t, alice.do(http.MethodPost, "/threads/"+th.ID.String()+"/posts", CreatePostForm{Title: "Other", Content: "Newer"}), http.StatusCreated, &other)
+	decode(t, alice.do(http.MethodPut, "/posts/"+other.ID.String()+"/vote", apiVote{Value: 1}), http.StatusOK, nil)
+
+	if w := alice.form("/posts/"+announcement.ID.String()+"/pin", nil); w.Code != http.StatusFound {
+		t.Fatalf("pinning the post = %d, want %d", w.Code, http.StatusFound)
+	}
+
+	for _, sort := range []string{"hot", "new", "top", "controversial"} {
+		var l struct {
+			Data []apiPost `json:"data"`
+		}
+		decode(t, alice.do(http.MethodGet, "/threads/"+th.ID.String()+"/posts?sort="+sort, nil), http.StatusOK, &l)
+		if len(l.Data) != 2 || l.Data[0].ID != announcement.ID || !l.Data[0].Pinned {
+			t.Errorf("posts sorted by %s = %+v, want the pinned post first", sort, l.Data)
+		}
+	}
+}
+
+func TestEditThread(t *testing.T) {
+	h, store, _ := newTestHandler(t)
+	aliceUser, alice := newAPIUserClient(t, h, store, "alice")
+	bobUser, bob := newAPIUserClient(t, h, store, "bob")
+	ctx := context.Background()
+
+	var th apiThread
+	decode(t, alice.do(http.MethodPost, "/threads", CreateThreadForm{Title: "Go", Description: "Go"}), http.StatusCreated, &th)
+	edit := "/threads/" + th.ID.String() + "/edit"
+
+	wantError(t, bob.do(http.MethodPut, "/threads/"+th.ID.String(), CreateThreadForm{Title: "Mine", Description: "Mine"}), http.StatusForbidden)
+	if w := bob.form(edit, url.Values{"title": {"Mine"}, "description": {"Mine"}}); w.Code != http.StatusForbidden {
+		t.Errorf("bob editing the thread = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	// The thread's creator moderates it, so the edit is logged.
+	if w := alice.form(edit, url.Values{"title": {"Go"}, "description": {"Be nice."}, "reason": {"New rules"}}); w.Code != http.StatusFound {
+		t.Fatalf("alice editing the thread = %d, want %d", w.Code, http.StatusFound)
+	}
+
+	// Moderators the creator appoints may edit the thread too.
+	if err := store.AddModerator(ctx, &goreddit.ThreadModerator{ThreadID: th.ID, UserID: bobUser.ID}); err != nil {
+		t.Fatal(err)
+	}
+	decode(t, bob.do(http.MethodPut, "/threads/"+th.ID.String(), CreateThreadForm{Title: "Go", Description: "Be very nice."}), http.StatusOK, nil)
+
+	// A creator who has stepped down can still edit, but not as a moderator.
+	if err := store.RemoveModerator(ctx, th.ID, aliceUser.ID); err != nil {
+		t.Fatal(err)
+	}
+	decode(t, alice.do(http.MethodPut, "/threads/"+th.ID.String(), CreateThreadForm{Title: "Golang", Description: "Be very nice."}), http.StatusOK, nil)
+
+	log, err := store.ModLog(ctx, th.ID, goreddit.ModLogFilter{Action: goreddit.ModEditThread}, goreddit.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 2 {
+		t.Fatalf("edit log = %+v, want 2 entries", log)
+	}
+	reasons := map[uuid.UUID]string{}
+	for _, e := range log {
+		reasons[e.ActorID] = e.Reason
+	}
+	if got, ok := reasons[aliceUser.ID]; !ok || got != "New rules" {
+		t.Errorf("alice's edit reason = %q (logged %v), want %q", got, ok, "New rules")
+	}
+	if _, ok := reasons[bobUser.ID]; !ok {
+		t.Errorf("edit log = %+v, want bob's edit", log)
+	}
+
+	got, err := store.Thread(ctx, th.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Golang" || got.Description != "Be very nice." {
+		t.Errorf("thread = %+v, want the last edit", got)
+	}
+}
@@ -0,0 +1,82 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+// banDurations are how long a moderator may ban a user from a thread, or an
+// admin suspend them, for. Permanent bans have no duration.
+var banDurations = map[string]time.Duration{
+	"1d":        24 * time.Hour,
+	"7d":        7 * 24 * time.Hour,
+	"30d":       30 * 24 * time.Hour,
+	"permanent": 0,
+}
+
+// banExpiry returns when a ban of the given duration starting now ends, or
+// nil if it never does.
+func banExpiry(duration string) *time.Time {
+	d := banDurations[duration]
+	if d == 0 {
+		return nil
+	}
+
+	t := time.Now().Add(d)
+	return &t
+}
+
+// shutOut returns why user may not post, comment or vote in a thread, or ""
+// if they may. Suspended users are shut out everywhere; pass uuid.Nil as
+// threadID to only check for a suspension. Admins are never shut out.
+func shutOut(ctx context.Context, store goreddit.Store, user goreddit.User, threadID uuid.UUID) (string, error) {
+	if user.IsAdmin() {
+		return "", nil
+	}
+
+	s, err := store.Suspension(ctx, user.ID)
+	if err == nil {
+		return suspendedMessage(s), nil
+	}
+	if !errors.Is(err, goreddit.ErrNotFound) {
+		return "", err
+	}
+	if threadID == uuid.Nil {
+		return "", nil
+	}
+
+	b, err := store.ThreadBan(ctx, threadID, user.ID)
+	if errors.Is(err, goreddit.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return "You have been banned from this thread" + until(b.ExpiresAt) + withReason(b.Reason), nil
+}
+
+func suspendedMessage(s goreddit.Suspension) string {
+	return "Your account has been suspended" + until(s.ExpiresAt) + withReason(s.Reason)
+}
+
+func until(expiresAt *time.Time) string {
+	if expiresAt == nil {
+		return "."
+	}
+
+	return fmt.Sprintf(" until %s.", expiresAt.Format("Jan 2, 2006 15:04 MST"))
+}
+
+func withReason(reason string) string {
+	if reason == "" {
+		return ""
+	}
+
+	return " Reason: " + reason
+}
@@ -1,16 +1,21 @@
 package web
 
 import (
+	"errors"
+	"html/template"
 	"net/http"
+	"time"
 
 	"github.com/alexedwards/scs/v2"
 	"github.com/blrobin2/goreddit"
 	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
 )
 
 type CommentHandler struct {
 	store    goreddit.Store
 	sessions *scs.SessionManager
+	pages    *errorPages
 }
 
 func (h *CommentHandler) Create() http.HandlerFunc {
@@ -21,6 +26,33 @@ func (h *CommentHandler) Create() http.HandlerFunc {
 			return
 		}
 
+		p, err := h.store.Post(r.Context(), postID)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		msg, err := shutOut(r.Context(), h.store, user, p.ThreadID)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if msg != "" {
+			h.pages.render(w, r, http.StatusForbidden, msg)
+			return
+		}
+
+		locked, err := lockedFor(r.Context(), h.store, user, p)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if locked {
+			h.pages.render(w, r, http.StatusForbidden, "This post is locked, so it takes no new comments.")
+			return
+		}
+
 		form := CreateCommentForm{
 			Content: r.FormValue("content"),
 		}
@@ -30,12 +62,39 @@ func (h *CommentHandler) Create() http.HandlerFunc {
 			return
 		}
 
-		if err := h.store.CreateComment(&goreddit.Comment{
-			ID:      uuid.New(),
-			PostID:  postID,
-			Content: form.Content,
+		var parentID uuid.UUID
+		if v := r.FormValue("parent_id"); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			parent, err := h.store.Comment(r.Context(), id)
+			if errors.Is(err, goreddit.ErrNotFound) {
+				http.Error(w, "Parent comment does not exist.", http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				h.pages.storeError(w, r, err)
+				return
+			}
+			if parent.PostID != postID {
+				http.Error(w, "Parent comment belongs to another post.", http.StatusBadRequest)
+				return
+			}
+			parentID = parent.ID
+		}
+
+		if err := h.store.CreateComment(r.Context(), &goreddit.Comment{
+			ID:          uuid.New(),
+			PostID:      postID,
+			UserID:      user.ID,
+			ParentID:    parentID,
+			Content:     form.Content,
+			ContentHTML: renderMarkdown(form.Content),
 		}); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			h.pages.storeError(w, r, err)
 			return
 		}
 
@@ -45,6 +104,208 @@ func (h *CommentHandler) Create() http.HandlerFunc {
 	}
 }
 
+func (h *CommentHandler) Edit() http.HandlerFunc {
+	type data struct {
+		SessionData
+		CSRF    template.HTML
+		Comment goreddit.Comment
+	}
+
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/comment_edit.html"))
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		if c.Deleted || !canModify(user, c.UserID) {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		sd := GetSessionData(h.sessions, r.Context())
+		if _, ok := sd.Form.(CreateCommentForm); !ok {
+			sd.Form = CreateCommentForm{Content: c.Content}
+		}
+
+		templ.Execute(w, data{
+			SessionData: sd,
+			CSRF:        csrf.TemplateField(r),
+			Comment:     c,
+		})
+	}
+}
+
+func (h *CommentHandler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		if c.Deleted || !canModify(user, c.UserID) {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		form := CreateCommentForm{
+			Content: r.FormValue("content"),
+		}
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(w, r, r.Referer(), http.StatusFound)
+			return
+		}
+
+		now := time.Now()
+		c.Content = form.Content
+		c.ContentHTML = renderMarkdown(form.Content)
+		c.EditedAt = &now
+		if err := h.store.UpdateComment(r.Context(), &c); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", "Your comment has been updated.")
+
+		http.Redirect(w, r, "/posts/"+c.PostID.String(), http.StatusFound)
+	}
+}
+
+func (h *CommentHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		ok, err := canRemove(r.Context(), h.store, user, c.UserID, p.ThreadID)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if c.Deleted || !ok {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		if err := h.store.DeleteComment(r.Context(), id); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		if c.UserID == user.ID {
+			h.sessions.Put(r.Context(), "flash", "Your comment has been deleted.")
+		} else {
+			if err := logModAction(r.Context(), h.store, user, commentEntry(goreddit.ModRemoveComment, c, p, modReason(r))); err != nil {
+				h.pages.storeError(w, r, err)
+				return
+			}
+			h.sessions.Put(r.Context(), "flash", "The comment has been removed.")
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *CommentHandler) Report() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if c.Deleted {
+			h.pages.notFound(w, r)
+			return
+		}
+
+		fileReport(w, r, h.store, h.sessions, c.PostID, c.ID)
+	}
+}
+
+func (h *CommentHandler) Approve() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		ok, err := canModerate(r.Context(), h.store, user, p.ThreadID)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if !ok {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		if err := h.store.ClearReports(r.Context(), p.ID, c.ID); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if err := logModAction(r.Context(), h.store, user, commentEntry(goreddit.ModApproveComment, c, p, modReason(r))); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", "The comment has been approved.")
+
+		http.Redirect(w, r, backTo(r, "/posts/"+p.ID.String()+"?comment="+c.ID.String()), http.StatusFound)
+	}
+}
+
 func (h *CommentHandler) Upvote() http.HandlerFunc {
 	return voteOnComment(h, 1)
 }
@@ -53,23 +314,48 @@ func (h *CommentHandler) Downvote() http.HandlerFunc {
 	return voteOnComment(h, -1)
 }
 
-func voteOnComment(h *CommentHandler, newVoteAmount int) http.HandlerFunc {
+func voteOnComment(h *CommentHandler, value int) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+
 		id, err := getId(r, "id")
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		c, err := h.store.Comment(id)
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		msg, err := shutOut(r.Context(), h.store, user, p.ThreadID)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if msg != "" {
+			http.Error(w, msg, http.StatusForbidden)
+			return
+		}
+
+		locked, err := lockedFor(r.Context(), h.store, user, p)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if locked {
+			http.Error(w, "This post is locked, so it takes no new votes.", http.StatusForbidden)
 			return
 		}
 
-		c.Votes += newVoteAmount
-		if err := h.store.UpdateComment(&c); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		if _, err := h.store.ToggleCommentVote(r.Context(), id, user.ID, value); err != nil {
+			h.pages.storeError(w, r, err)
 			return
 		}
 
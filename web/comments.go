@@ -0,0 +1,84 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+type commentNode struct {
+	goreddit.Comment
+	Indent         int
+	ContinueThread bool
+	CanEdit        bool
+	CanRemove      bool
+}
+
+// commentTree prepares a depth-first list of comments for rendering. When
+// rootID is set only that comment and its replies are kept. Replies nested
+// deeper than maxDepth below the root are dropped and their closest visible
+// ancestor is marked so the template can link to the rest of the thread.
+func commentTree(cs []goreddit.Comment, rootID uuid.UUID, maxDepth int) []commentNode {
+	base := 0
+	if rootID != uuid.Nil {
+		start := len(cs)
+		for i, c := range cs {
+			if c.ID == rootID {
+				start = i
+				break
+			}
+		}
+		if start == len(cs) {
+			return []commentNode{}
+		}
+
+		end := start + 1
+		for end < len(cs) && cs[end].Depth > cs[start].Depth {
+			end++
+		}
+
+		base = cs[start].Depth
+		cs = cs[start:end]
+	}
+
+	nodes := []commentNode{}
+	for i, c := range cs {
+		indent := c.Depth - base
+		if indent > maxDepth {
+			continue
+		}
+
+		hasReplies := i+1 < len(cs) && cs[i+1].Depth > c.Depth
+		nodes = append(nodes, commentNode{
+			Comment:        c,
+			Indent:         indent,
+			ContinueThread: indent == maxDepth && hasReplies,
+		})
+	}
+
+	return nodes
+}
+
+// paginateTree paginates a comment tree by its top-level comments, keeping
+// each one together with its replies.
+func paginateTree(r *http.Request, p goreddit.Page, cs []goreddit.Comment) ([]goreddit.Comment, Pagination) {
+	var roots []int
+	for i, c := range cs {
+		if c.Depth == 0 {
+			roots = append(roots, i)
+		}
+	}
+
+	start, end, nav := paginate(r, p, len(roots), func(i int) uuid.UUID { return cs[roots[i]].ID })
+	if start == end {
+		return []goreddit.Comment{}, nav
+	}
+
+	last := len(cs)
+	if end < len(roots) {
+		last = roots[end]
+	}
+
+	return cs[roots[start]:last], nav
+}
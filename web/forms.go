@@ -1,6 +1,12 @@
 package web
 
-import "encoding/gob"
+import (
+	"encoding/gob"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/blrobin2/goreddit"
+)
 
 func init() {
 	gob.Register(CreatePostForm{})
@@ -8,35 +14,60 @@ func init() {
 	gob.Register(CreateCommentForm{})
 	gob.Register(RegisterUserForm{})
 	gob.Register(LoginUserForm{})
+	gob.Register(CreateTokenForm{})
+	gob.Register(AddModeratorForm{})
+	gob.Register(BanUserForm{})
+	gob.Register(SuspendUserForm{})
 	gob.Register(FormErrors{})
 }
 
 type FormErrors map[string]string
 
 type CreatePostForm struct {
-	Title   string
-	Content string
-
-	Errors FormErrors
+	Title   string `json:"title"`
+	Kind    string `json:"kind"`
+	URL     string `json:"url"`
+	Content string `json:"content"`
+
+	// DuplicateID is the post that already links to URL in the thread.
+	DuplicateID string     `json:"-"`
+	Errors      FormErrors `json:"-"`
 }
 
+// Validate checks the form and normalizes URL. Link posts need a link but no
+// text; text posts, including those submitted without a kind, need text.
+// Image posts need no text either; the image itself is checked on upload.
 func (f *CreatePostForm) Validate() bool {
 	f.Errors = FormErrors{}
 	if f.Title == "" {
 		f.Errors["Title"] = "Please enter a title."
 	}
-	if f.Content == "" {
-		f.Errors["Content"] = "Please enter some text."
+	switch goreddit.PostKind(f.Kind) {
+	case "", goreddit.PostText:
+		f.URL = ""
+		if f.Content == "" {
+			f.Errors["Content"] = "Please enter some text."
+		}
+	case goreddit.PostImage:
+		f.URL = ""
+	case goreddit.PostLink:
+		if link, ok := linkURL(f.URL); ok {
+			f.URL = link
+		} else {
+			f.Errors["URL"] = fmt.Sprintf("Please enter a web address starting with http:// or https://, up to %d characters.", MaxURLLength)
+		}
+	default:
+		f.Errors["Kind"] = "Please choose a text post or a link."
 	}
 
 	return len(f.Errors) == 0
 }
 
 type CreateThreadForm struct {
-	Title       string
-	Description string
+	Title       string `json:"title"`
+	Description string `json:"description"`
 
-	Errors FormErrors
+	Errors FormErrors `json:"-"`
 }
 
 func (f *CreateThreadForm) Validate() bool {
@@ -52,9 +83,9 @@ func (f *CreateThreadForm) Validate() bool {
 }
 
 type CreateCommentForm struct {
-	Content string
+	Content string `json:"content"`
 
-	Errors FormErrors
+	Errors FormErrors `json:"-"`
 }
 
 func (f *CreateCommentForm) Validate() bool {
@@ -67,12 +98,12 @@ func (f *CreateCommentForm) Validate() bool {
 }
 
 type RegisterUserForm struct {
-	Username        string
-	Password        string
-	PasswordConfirm string
-	UsernameTaken   bool
+	Username        string `json:"username"`
+	Password        string `json:"password"`
+	PasswordConfirm string `json:"password_confirm"`
+	UsernameTaken   bool   `json:"-"`
 
-	Errors FormErrors
+	Errors FormErrors `json:"-"`
 }
 
 func (f *RegisterUserForm) Validate() bool {
@@ -97,11 +128,12 @@ func (f *RegisterUserForm) Validate() bool {
 }
 
 type LoginUserForm struct {
-	Username                  string
-	Password                  string
-	InvalidUsernameOrPassword bool
+	Username                  string `json:"username"`
+	Password                  string `json:"password"`
+	InvalidUsernameOrPassword bool   `json:"-"`
+	Suspended                 string `json:"-"`
 
-	Errors FormErrors
+	Errors FormErrors `json:"-"`
 }
 
 func (f *LoginUserForm) Validate() bool {
@@ -110,6 +142,8 @@ func (f *LoginUserForm) Validate() bool {
 		f.Errors["Username"] = "Please enter a username."
 	} else if f.InvalidUsernameOrPassword {
 		f.Errors["Username"] = "Username or password is incorrect."
+	} else if f.Suspended != "" {
+		f.Errors["Username"] = f.Suspended
 	}
 	if f.Password == "" {
 		f.Errors["Password"] = "Please enter a password."
@@ -117,3 +151,105 @@ func (f *LoginUserForm) Validate() bool {
 
 	return len(f.Errors) == 0
 }
+
+type CreateTokenForm struct {
+	Name string `json:"name"`
+
+	Errors FormErrors `json:"-"`
+}
+
+func (f *CreateTokenForm) Validate() bool {
+	f.Errors = FormErrors{}
+	if f.Name == "" {
+		f.Errors["Name"] = "Please enter a name."
+	} else if len(f.Name) > 100 {
+		f.Errors["Name"] = "Name must be at most 100 characters long."
+	}
+
+	return len(f.Errors) == 0
+}
+
+type AddModeratorForm struct {
+	Username         string `json:"username"`
+	UnknownUser      bool   `json:"-"`
+	AlreadyModerator bool   `json:"-"`
+
+	Errors FormErrors `json:"-"`
+}
+
+func (f *AddModeratorForm) Validate() bool {
+	f.Errors = FormErrors{}
+	if f.Username == "" {
+		f.Errors["Username"] = "Please enter a username."
+	} else if f.UnknownUser {
+		f.Errors["Username"] = "There is no user with that name."
+	} else if f.AlreadyModerator {
+		f.Errors["Username"] = "This user is already a moderator."
+	}
+
+	return len(f.Errors) == 0
+}
+
+type ReportForm struct {
+	Reason string `json:"reason"`
+
+	Errors FormErrors `json:"-"`
+}
+
+func (f *ReportForm) Validate() bool {
+	f.Errors = FormErrors{}
+	if f.Reason == "" {
+		f.Errors["Reason"] = "Please say what is wrong."
+	} else if utf8.RuneCountInString(f.Reason) > maxReasonLength {
+		f.Errors["Reason"] = fmt.Sprintf("Reason must be at most %d characters long.", maxReasonLength)
+	}
+
+	return len(f.Errors) == 0
+}
+
+type BanUserForm struct {
+	Username    string `json:"username"`
+	Reason      string `json:"reason"`
+	Duration    string `json:"duration"`
+	UnknownUser bool   `json:"-"`
+	Moderator   bool   `json:"-"`
+
+	Errors FormErrors `json:"-"`
+}
+
+func (f *BanUserForm) Validate() bool {
+	f.Errors = FormErrors{}
+	if f.Username == "" {
+		f.Errors["Username"] = "Please enter a username."
+	} else if f.UnknownUser {
+		f.Errors["Username"] = "There is no user with that name."
+	} else if f.Moderator {
+		f.Errors["Username"] = "Moderators and admins cannot be banned."
+	}
+	validateBan(f.Errors, f.Reason, f.Duration)
+
+	return len(f.Errors) == 0
+}
+
+type SuspendUserForm struct {
+	Reason   string `json:"reason"`
+	Duration string `json:"duration"`
+
+	Errors FormErrors `json:"-"`
+}
+
+func (f *SuspendUserForm) Validate() bool {
+	f.Errors = FormErrors{}
+	validateBan(f.Errors, f.Reason, f.Duration)
+
+	return len(f.Errors) == 0
+}
+
+func validateBan(errs FormErrors, reason, duration string) {
+	if utf8.RuneCountInString(reason) > maxReasonLength {
+		errs["Reason"] = fmt.Sprintf("Reason must be at most %d characters long.", maxReasonLength)
+	}
+	if _, ok := banDurations[duration]; !ok {
+		errs["Duration"] = "Please choose how long for."
+	}
+}
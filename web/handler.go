@@ -2,9 +2,10 @@ package web
 
 import (
 	"context"
+	"errors"
 	"html/template"
 	"net/http"
-	"sort"
+	"strings"
 
 	"github.com/alexedwards/scs/v2"
 	"github.com/blrobin2/goreddit"
@@ -14,50 +15,117 @@ import (
 	"github.com/gorilla/csrf"
 )
 
-func NewHandler(store goreddit.Store, sessions *scs.SessionManager, csrfKey []byte) *Handler {
+func NewHandler(store goreddit.Store, blobs goreddit.BlobStore, sessions *scs.SessionManager, csrfKey []byte) *Handler {
 	h := &Handler{
 		Mux:      chi.NewMux(),
 		store:    store,
+		blobs:    blobs,
 		sessions: sessions,
 	}
 
-	threads := ThreadHandler{store: store, sessions: sessions}
-	posts := PostHandler{store: store, sessions: sessions}
-	comments := CommentHandler{store: store, sessions: sessions}
-	users := UserHandler{store: store, sessions: sessions}
+	pages := newErrorPages(sessions)
+	threads := ThreadHandler{store: store, blobs: blobs, sessions: sessions, pages: pages}
+	posts := PostHandler{store: store, blobs: blobs, sessions: sessions, pages: pages}
+	comments := CommentHandler{store: store, sessions: sessions, pages: pages}
+	users := UserHandler{store: store, sessions: sessions, pages: pages}
+	search := SearchHandler{store: store, sessions: sessions, pages: pages}
+	tokens := TokenHandler{store: store, sessions: sessions}
+	api := APIHandler{store: store, blobs: blobs, sessions: sessions}
 
 	h.Use(middleware.Logger)
-	h.Use(csrf.Protect(csrfKey, csrf.Secure(false)))
+	h.Use(limitBody)
 	h.Use(sessions.LoadAndSave)
 	h.Use(h.withUser)
+	h.Use(h.withAPIToken)
 
-	h.Get("/", h.Home())
-	h.Route("/threads", func(r chi.Router) {
-		r.Get("/", threads.List())
-		r.Get("/new", threads.New())
-		r.Post("/", threads.Create())
-		r.Get("/{id}", threads.Show())
-		r.Delete("/{id}", threads.Delete())
+	h.Route("/api/v1", api.Routes)
+	// Images are cached publicly, so they are served without the CSRF
+	// cookie the rest of the site sets.
+	h.Get("/images/{name}", h.Image())
+	h.Head("/images/{name}", h.Image())
+	h.NotFound(pages.notFound)
 
-		r.Get("/{id}/new", posts.New())
-		r.Post("/{id}", posts.Create())
-	})
-	h.Route("/posts", func(r chi.Router) {
-		r.Get("/{postID}", posts.Show())
-		r.Post("/{postID}", comments.Create())
-		r.Post("/{id}/upvote", posts.Upvote())
-		r.Post("/{id}/downvote", posts.Downvote())
-	})
+	h.Group(func(r chi.Router) {
+		r.Use(csrf.Protect(csrfKey, csrf.Secure(false)))
+
+		login := r.With(pages.requireLogin)
+
+		r.Get("/", h.Home())
+		r.Get("/search", search.Search())
+		r.Get("/domain/{host}", h.Domain())
+		login.Post("/preview", h.Preview())
+		r.Route("/threads", func(r chi.Router) {
+			login := r.With(pages.requireLogin)
+			createThread := login.With(pages.requireKarma(&MinKarma.CreateThread, "create threads"))
+			createPost := login.With(pages.requireKarma(&MinKarma.CreatePost, "submit posts"))
+
+			r.Get("/", threads.List())
+			createThread.Get("/new", threads.New())
+			createThread.Post("/", threads.Create())
+			r.Get("/{id}", threads.Show())
+			login.Get("/{id}/edit", threads.Edit())
+			login.Post("/{id}/edit", threads.Update())
+			login.Delete("/{id}", threads.Delete())
+			r.Get("/{id}/moderators", threads.Moderators())
+			r.Get("/{id}/modlog", threads.ModLog())
+			login.Get("/{id}/queue", threads.Queue())
+			login.Post("/{id}/moderators", threads.AddModerator())
+			login.Delete("/{id}/moderators/{userID}", threads.RemoveModerator())
+			login.Get("/{id}/bans", threads.Bans())
+			login.Post("/{id}/bans", threads.Ban())
+			login.Delete("/{id}/bans/{userID}", threads.Unban())
+
+			createPost.Get("/{id}/new", posts.New())
+			createPost.Post("/{id}", posts.Create())
+		})
+		r.Route("/posts", func(r chi.Router) {
+			login := r.With(pages.requireLogin)
+			comment := login.With(pages.requireKarma(&MinKarma.CreateComment, "comment"))
+
+			r.Get("/{postID}", posts.Show())
+			comment.Post("/{postID}", comments.Create())
+			login.Get("/{id}/edit", posts.Edit())
+			login.Post("/{id}/edit", posts.Update())
+			login.Delete("/{id}", posts.Delete())
+			login.Post("/{id}/upvote", posts.Upvote())
+			login.Post("/{id}/downvote", posts.Downvote())
+			login.Post("/{id}/lock", posts.Lock())
+			login.Post("/{id}/unlock", posts.Unlock())
+			login.Post("/{id}/pin", posts.Pin())
+			login.Post("/{id}/unpin", posts.Unpin())
+			login.Post("/{id}/report", posts.Report())
+			login.Post("/{id}/approve", posts.Approve())
+		})
+
+		r.Route("/comments", func(r chi.Router) {
+			login := r.With(pages.requireLogin)
 
-	h.Route("/comments", func(r chi.Router) {
-		r.Post("/{id}/upvote", comments.Upvote())
-		r.Post("/{id}/downvote", comments.Downvote())
+			login.Get("/{id}/edit", comments.Edit())
+			login.Post("/{id}/edit", comments.Update())
+			login.Delete("/{id}", comments.Delete())
+			login.Post("/{id}/upvote", comments.Upvote())
+			login.Post("/{id}/downvote", comments.Downvote())
+			login.Post("/{id}/report", comments.Report())
+			login.Post("/{id}/approve", comments.Approve())
+		})
+		r.Route("/u/{username}", func(r chi.Router) {
+			r.Get("/", users.Posts())
+			r.Get("/comments", users.Comments())
+			r.With(pages.requireLogin).Post("/suspend", users.Suspend())
+			r.With(pages.requireLogin).Post("/unsuspend", users.Unsuspend())
+		})
+		r.Get("/register", users.New())
+		r.Post("/register", users.Register())
+		r.Get("/login", users.LoginForm())
+		r.Post("/login", users.Login())
+		r.Get("/logout", users.Logout())
+
+		login.Route("/tokens", func(r chi.Router) {
+			r.Get("/", tokens.List())
+			r.Post("/", tokens.Create())
+			r.Delete("/{id}", tokens.Delete())
+		})
 	})
-	h.Get("/register", users.New())
-	h.Post("/register", users.Register())
-	h.Get("/login", users.LoginForm())
-	h.Post("/login", users.Login())
-	h.Get("/logout", users.Logout())
 
 	return h
 }
@@ -66,6 +134,7 @@ type Handler struct {
 	*chi.Mux
 
 	store    goreddit.Store
+	blobs    goreddit.BlobStore
 	sessions *scs.SessionManager
 }
 
@@ -74,28 +143,88 @@ func (h *Handler) Home() http.HandlerFunc {
 		SessionData
 		CSRFToken string
 		Posts     []goreddit.Post
+		Listing   ListingTabs
+		Page      Pagination
 	}
 
-	templ := template.Must(template.ParseFiles("templates/layout.html", "templates/home.html"))
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/home.html"))
 	return func(w http.ResponseWriter, r *http.Request) {
-		ps, err := h.store.Posts()
+		l, tabs := postListing(r)
+		page, err := pageRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ps, err := h.store.Posts(r.Context(), l, page)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		sort.SliceStable(ps, func(i, j int) bool {
-			return ps[i].Votes > ps[j].Votes
+		start, end, nav := paginate(r, page, len(ps), func(i int) uuid.UUID { return ps[i].ID })
+
+		templ.Execute(w, data{
+			SessionData: GetSessionData(h.sessions, r.Context()),
+			CSRFToken:   csrf.Token(r),
+			Posts:       ps[start:end],
+			Listing:     tabs,
+			Page:        nav,
 		})
+	}
+}
+
+// Domain lists the link posts to a domain across all threads.
+func (h *Handler) Domain() http.HandlerFunc {
+	type data struct {
+		SessionData
+		CSRFToken string
+		Domain    string
+		Posts     []goreddit.Post
+		Listing   ListingTabs
+		Page      Pagination
+	}
+
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/domain.html"))
+	return func(w http.ResponseWriter, r *http.Request) {
+		domain := strings.TrimPrefix(strings.ToLower(chi.URLParam(r, "host")), "www.")
+		l, tabs := postListing(r)
+		page, err := pageRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ps, err := h.store.PostsByDomain(r.Context(), domain, l, page)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		start, end, nav := paginate(r, page, len(ps), func(i int) uuid.UUID { return ps[i].ID })
 
 		templ.Execute(w, data{
 			SessionData: GetSessionData(h.sessions, r.Context()),
 			CSRFToken:   csrf.Token(r),
-			Posts:       ps,
+			Domain:      domain,
+			Posts:       ps[start:end],
+			Listing:     tabs,
+			Page:        nav,
 		})
 	}
 }
 
+// backTo returns where to send the user after a form is handled: the local
+// path in the form's "next" field if there is one, fallback otherwise.
+func backTo(r *http.Request, fallback string) string {
+	next := r.FormValue("next")
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return fallback
+	}
+
+	return next
+}
+
 func getId(r *http.Request, idName string) (uuid.UUID, error) {
 	idStr := chi.URLParam(r, idName)
 	return uuid.Parse(idStr)
@@ -104,14 +233,64 @@ func getId(r *http.Request, idName string) (uuid.UUID, error) {
 func (h *Handler) withUser(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		id, _ := h.sessions.Get(r.Context(), "user_id").(uuid.UUID)
+		if id == uuid.Nil {
+			next.ServeHTTP(w, r)
+			return
+		}
 
-		user, err := h.store.User(id)
-		if err != nil {
+		user, err := h.store.User(r.Context(), id)
+		if errors.Is(err, goreddit.ErrNotFound) {
 			next.ServeHTTP(w, r)
 			return
 		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
 		ctx := context.WithValue(r.Context(), KeyUserID, user)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// withAPIToken authenticates requests carrying an "Authorization: Bearer"
+// header as the token's owner, in place of any session. Such requests cannot
+// be forged by another site, so they are exempt from CSRF checks.
+func (h *Handler) withAPIToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if auth == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		plain := strings.TrimPrefix(auth, "Bearer ")
+		if plain == auth {
+			writeError(w, http.StatusUnauthorized, "Authorization header must be a Bearer token.")
+			return
+		}
+
+		t, err := h.store.UseAPIToken(r.Context(), hashAPIToken(plain))
+		if errors.Is(err, goreddit.ErrNotFound) {
+			writeError(w, http.StatusUnauthorized, "Invalid API token.")
+			return
+		}
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		user, err := h.store.User(r.Context(), t.UserID)
+		if errors.Is(err, goreddit.ErrNotFound) {
+			writeError(w, http.StatusUnauthorized, "Invalid API token.")
+			return
+		}
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), KeyUserID, user)
+		next.ServeHTTP(w, csrf.UnsafeSkipCheck(r.WithContext(ctx)))
+	})
+}
@@ -0,0 +1,68 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/blrobin2/goreddit/localfs"
+	"github.com/blrobin2/goreddit/memory"
+	"github.com/google/uuid"
+)
+
+// The handlers parse their templates by paths relative to the repository
+// root, as the server is run from there.
+func TestMain(m *testing.M) {
+	if err := os.Chdir(".."); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+// newTestHandler returns a handler over an empty memory store and a blob
+// store in a temporary directory.
+func newTestHandler(t *testing.T) (*Handler, goreddit.Store, goreddit.BlobStore) {
+	t.Helper()
+	store := memory.NewStore()
+	blobs, err := localfs.NewBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewHandler(store, blobs, NewSessionManager(nil), []byte("01234567890123456789012345678901")), store, blobs
+}
+
+// userCounter counts the users looked up in the store it wraps.
+type userCounter struct {
+	goreddit.Store
+	lookups int
+}
+
+func (s *userCounter) User(ctx context.Context, id uuid.UUID) (goreddit.User, error) {
+	s.lookups++
+	return s.Store.User(ctx, id)
+}
+
+func TestWithUserAnonymous(t *testing.T) {
+	store := &userCounter{Store: memory.NewStore()}
+	blobs, err := localfs.NewBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(store, blobs, NewSessionManager(nil), []byte("01234567890123456789012345678901"))
+
+	for _, path := range []string{"/", "/threads", "/login"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s = %d, want %d", path, w.Code, http.StatusOK)
+		}
+	}
+	if store.lookups != 0 {
+		t.Errorf("anonymous requests looked up %d users, want 0", store.lookups)
+	}
+}
@@ -0,0 +1,260 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"net/http"
+	"path"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// MaxImageSize is the largest image, in bytes, that may be uploaded with a
+// post.
+var MaxImageSize int64 = 10 << 20
+
+// maxImagePixels keeps a small, highly compressed upload from decoding into
+// an image too large to hold in memory.
+const maxImagePixels = 24_000_000
+
+// thumbnailSize is the longest side of a thumbnail, in pixels.
+const thumbnailSize = 320
+
+// imageTypes are the types of image that may be uploaded, by the content
+// type sniffed from their first bytes, with the extension they are stored
+// under.
+var imageTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+}
+
+// upload is an image submitted with a post, checked and thumbnailed but not
+// yet stored. The image is kept as it was uploaded.
+type upload struct {
+	image, thumbnail       []byte
+	imageExt, thumbnailExt string
+}
+
+// readUpload reads the image uploaded in a form field. It returns why the
+// image can't be used, or "" if it can.
+func readUpload(r *http.Request, field string) (upload, string) {
+	f, fh, err := r.FormFile(field)
+	if errors.Is(err, http.ErrMissingFile) {
+		return upload{}, "Please choose an image to upload."
+	}
+	if err != nil {
+		return upload{}, "The image could not be uploaded. Please try again."
+	}
+	defer f.Close()
+
+	tooLarge := fmt.Sprintf("Images can be at most %d MB.", MaxImageSize>>20)
+	if fh.Size > MaxImageSize {
+		return upload{}, tooLarge
+	}
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(f); err != nil {
+		return upload{}, "The image could not be uploaded. Please try again."
+	}
+	if int64(buf.Len()) > MaxImageSize {
+		return upload{}, tooLarge
+	}
+
+	// The type the browser sent is ignored: only what the file starts with
+	// decides how it is read and served.
+	ext, ok := imageTypes[http.DetectContentType(buf.Bytes())]
+	if !ok {
+		return upload{}, "Please upload a PNG, JPEG or GIF image."
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return upload{}, "That image could not be read."
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return upload{}, fmt.Sprintf("Images can be at most %d megapixels.", maxImagePixels/1_000_000)
+	}
+	img, _, err := image.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return upload{}, "That image could not be read."
+	}
+
+	// Photos make better thumbnails as JPEG; screenshots and images with
+	// transparency as PNG.
+	var thumb bytes.Buffer
+	thumbExt := ".png"
+	if ext == ".jpg" {
+		thumbExt = ".jpg"
+		err = jpeg.Encode(&thumb, thumbnail(img, thumbnailSize), &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&thumb, thumbnail(img, thumbnailSize))
+	}
+	if err != nil {
+		return upload{}, "That image could not be read."
+	}
+
+	return upload{image: buf.Bytes(), imageExt: ext, thumbnail: thumb.Bytes(), thumbnailExt: thumbExt}, ""
+}
+
+// storeUpload puts an image and its thumbnail in the blob store under keys
+// derived from the post's ID, and sets them on the post.
+func storeUpload(ctx context.Context, blobs goreddit.BlobStore, p *goreddit.Post, u upload) error {
+	imageKey := "images/" + p.ID.String() + u.imageExt
+	thumbKey := "images/" + p.ID.String() + "_thumb" + u.thumbnailExt
+	if err := blobs.PutBlob(ctx, imageKey, bytes.NewReader(u.image)); err != nil {
+		return err
+	}
+	if err := blobs.PutBlob(ctx, thumbKey, bytes.NewReader(u.thumbnail)); err != nil {
+		blobs.DeleteBlob(ctx, imageKey)
+		return err
+	}
+
+	p.Image, p.Thumbnail = imageKey, thumbKey
+	return nil
+}
+
+// deleteImages removes the image of a post that has been deleted. The post
+// is gone either way, so failures are only logged.
+func deleteImages(ctx context.Context, blobs goreddit.BlobStore, p goreddit.Post) {
+	for _, key := range []string{p.Image, p.Thumbnail} {
+		if key == "" {
+			continue
+		}
+		if err := blobs.DeleteBlob(ctx, key); err != nil {
+			log.Printf("error deleting image of post %s: %v", p.ID, err)
+		}
+	}
+}
+
+// deleteThread deletes a thread. Its posts go with it, and so must their
+// images.
+func deleteThread(ctx context.Context, store goreddit.Store, blobs goreddit.BlobStore, id uuid.UUID) error {
+	ps, err := store.PostsByThead(ctx, id, goreddit.PostListing{Sort: goreddit.SortNew}, goreddit.Page{})
+	if err != nil {
+		return err
+	}
+	if err := store.DeleteThread(ctx, id); err != nil {
+		return err
+	}
+	for _, p := range ps {
+		deleteImages(ctx, blobs, p)
+	}
+
+	return nil
+}
+
+// thumbnail scales img down to fit in a square of size pixels, averaging the
+// pixels that make up each pixel of the thumbnail. Smaller images are
+// returned as they are.
+func thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+
+	tw, th := size, size
+	if w > h {
+		th = h * size / w
+	} else {
+		tw = w * size / h
+	}
+	if tw == 0 {
+		tw = 1
+	}
+	if th == 0 {
+		th = 1
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := y*h/th, (y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := x*w/tw, (x+1)*w/tw
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			i := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[i+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+
+	return dst
+}
+
+// imageURL returns the path an image in the blob store is served from.
+func imageURL(key string) string {
+	return "/" + key
+}
+
+// Image serves an image from the blob store. Stored images never change, so
+// browsers and proxies may cache them for good.
+func (h *Handler) Image() http.HandlerFunc {
+	contentTypes := map[string]string{}
+	for ct, ext := range imageTypes {
+		contentTypes[ext] = ct
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		contentType, ok := contentTypes[path.Ext(name)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		b, err := h.blobs.Blob(r.Context(), "images/"+name)
+		if errors.Is(err, goreddit.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer b.Close()
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("ETag", `"`+name+`"`)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		http.ServeContent(w, r, name, b.ModTime, b)
+	}
+}
+
+// limitBody caps request bodies at the size of the largest image plus room
+// for the rest of a form, before anything reads them.
+func limitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := MaxImageSize + 1<<20
+		if r.ContentLength > limit {
+			http.Error(w, fmt.Sprintf("Requests can be at most %d MB.", limit>>20), http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+		next.ServeHTTP(w, r)
+	})
+}
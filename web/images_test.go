@@ -0,0 +1,289 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+// testImage returns a w by h image of one colour, encoded as format.
+func testImage(t *testing.T, format string, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+
+	var b bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&b, img)
+	case "jpeg":
+		err = jpeg.Encode(&b, img, nil)
+	case "gif":
+		err = gif.Encode(&b, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b.Bytes()
+}
+
+// pngHeader returns the start of a PNG that claims to be w by h, which is all
+// that is read to find its size.
+func pngHeader(w, h uint32) []byte {
+	var ihdr bytes.Buffer
+	ihdr.WriteString("IHDR")
+	binary.Write(&ihdr, binary.BigEndian, w)
+	binary.Write(&ihdr, binary.BigEndian, h)
+	ihdr.Write([]byte{8, 2, 0, 0, 0})
+
+	var b bytes.Buffer
+	b.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&b, binary.BigEndian, uint32(ihdr.Len()-4))
+	b.Write(ihdr.Bytes())
+	binary.Write(&b, binary.BigEndian, crc32.ChecksumIEEE(ihdr.Bytes()))
+
+	return b.Bytes()
+}
+
+// uploadRequest returns a form post with data uploaded as the image field,
+// or with no file if data is nil.
+func uploadRequest(t *testing.T, filename string, data []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("title", "Picture")
+	if data != nil {
+		fw, err := mw.CreateFormFile("image", filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(data)
+	}
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/threads/1", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	return r
+}
+
+func TestReadUpload(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		data     []byte
+		imageExt string
+		thumbExt string
+		thumbW   int
+		thumbH   int
+		msg      string
+	}{
+		{name: "png", filename: "a.png", data: testImage(t, "png", 640, 480), imageExt: ".png", thumbExt: ".png", thumbW: 320, thumbH: 240},
+		{name: "jpeg", filename: "a.jpg", data: testImage(t, "jpeg", 480, 640), imageExt: ".jpg", thumbExt: ".jpg", thumbW: 240, thumbH: 320},
+		{name: "gif", filename: "a.gif", data: testImage(t, "gif", 100, 50), imageExt: ".gif", thumbExt: ".png", thumbW: 100, thumbH: 50},
+		{name: "gif named png", filename: "a.png", data: testImage(t, "gif", 10, 10), imageExt: ".gif", thumbExt: ".png", thumbW: 10, thumbH: 10},
+		{name: "no file", msg: "Please choose an image to upload."},
+		{name: "text", filename: "a.png", data: []byte("hello, world"), msg: "Please upload a PNG, JPEG or GIF image."},
+		{name: "html", filename: "a.gif", data: []byte("<html><script>alert(1)</script>"), msg: "Please upload a PNG, JPEG or GIF image."},
+		{name: "webp", filename: "a.gif", data: []byte("RIFF\x24\x00\x00\x00WEBPVP8 \x18\x00\x00\x00"), msg: "Please upload a PNG, JPEG or GIF image."},
+		{name: "png header on garbage", filename: "a.png", data: append([]byte("\x89PNG\r\n\x1a\n"), "not really"...), msg: "That image could not be read."},
+		{name: "too many pixels", filename: "a.png", data: pngHeader(6000, 5000), msg: "Images can be at most 24 megapixels."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, msg := readUpload(uploadRequest(t, tt.filename, tt.data), "image")
+			if msg != tt.msg {
+				t.Fatalf("readUpload message = %q, want %q", msg, tt.msg)
+			}
+			if tt.msg != "" {
+				return
+			}
+
+			if u.imageExt != tt.imageExt || u.thumbnailExt != tt.thumbExt {
+				t.Errorf("readUpload extensions = %q, %q, want %q, %q", u.imageExt, u.thumbnailExt, tt.imageExt, tt.thumbExt)
+			}
+			if !bytes.Equal(u.image, tt.data) {
+				t.Errorf("readUpload changed the image")
+			}
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(u.thumbnail))
+			if err != nil {
+				t.Fatalf("thumbnail: %v", err)
+			}
+			if cfg.Width != tt.thumbW || cfg.Height != tt.thumbH {
+				t.Errorf("thumbnail is %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.thumbW, tt.thumbH)
+			}
+		})
+	}
+}
+
+func TestReadUploadSize(t *testing.T) {
+	defer func(size int64) { MaxImageSize = size }(MaxImageSize)
+	MaxImageSize = 1 << 20
+
+	small := testImage(t, "png", 10, 10)
+	if _, msg := readUpload(uploadRequest(t, "a.png", small), "image"); msg != "" {
+		t.Errorf("readUpload of a small image = %q", msg)
+	}
+
+	large := append(testImage(t, "png", 10, 10), make([]byte, MaxImageSize)...)
+	if _, msg := readUpload(uploadRequest(t, "a.png", large), "image"); msg != "Images can be at most 1 MB." {
+		t.Errorf("readUpload of a large image = %q, want it rejected", msg)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		w, h   int
+		tw, th int
+	}{
+		{1000, 500, 320, 160},
+		{500, 1000, 160, 320},
+		{321, 321, 320, 320},
+		{320, 320, 320, 320},
+		{100, 50, 100, 50},
+		{3000, 1, 320, 1},
+		{1, 3000, 1, 320},
+	}
+
+	for _, tt := range tests {
+		img := image.NewRGBA(image.Rect(0, 0, tt.w, tt.h))
+		for i := range img.Pix {
+			img.Pix[i] = 0x40
+		}
+
+		got := thumbnail(img, thumbnailSize)
+		if b := got.Bounds(); b.Dx() != tt.tw || b.Dy() != tt.th {
+			t.Errorf("thumbnail of %dx%d is %dx%d, want %dx%d", tt.w, tt.h, b.Dx(), b.Dy(), tt.tw, tt.th)
+		}
+		if c := color.RGBAModel.Convert(got.At(got.Bounds().Min.X, got.Bounds().Min.Y)).(color.RGBA); c != (color.RGBA{0x40, 0x40, 0x40, 0x40}) {
+			t.Errorf("thumbnail of %dx%d has colour %v, want the image's", tt.w, tt.h, c)
+		}
+	}
+}
+
+func TestImage(t *testing.T) {
+	h, store, blobs := newTestHandler(t)
+	ctx := context.Background()
+
+	data := testImage(t, "png", 640, 480)
+	u, msg := readUpload(uploadRequest(t, "a.png", data), "image")
+	if msg != "" {
+		t.Fatal(msg)
+	}
+	th := goreddit.Thread{ID: uuid.New(), Title: "Pictures"}
+	if err := store.CreateThread(ctx, &th); err != nil {
+		t.Fatal(err)
+	}
+	p := goreddit.Post{ID: uuid.New(), ThreadID: th.ID, Title: "Picture", Kind: goreddit.PostImage}
+	if err := storeUpload(ctx, blobs, &p, u); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreatePost(ctx, &p); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, imageURL(p.Image), nil))
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), data) {
+		t.Fatalf("GET %s = %d with %d bytes, want the image", imageURL(p.Image), w.Code, w.Body.Len())
+	}
+	name := strings.TrimPrefix(p.Image, "images/")
+	headers := map[string]string{
+		"Content-Type":           "image/png",
+		"Cache-Control":          "public, max-age=31536000, immutable",
+		"ETag":                   `"` + name + `"`,
+		"X-Content-Type-Options": "nosniff",
+	}
+	for k, v := range headers {
+		if got := w.Header().Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+	if c := w.Header().Get("Set-Cookie"); c != "" {
+		t.Errorf("image response sets a cookie: %q", c)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, imageURL(p.Image), nil)
+	r.Header.Set("If-None-Match", `"`+name+`"`)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("conditional GET = %d, want %d", w.Code, http.StatusNotModified)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodHead, imageURL(p.Thumbnail), nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("HEAD %s = %d, %q", imageURL(p.Thumbnail), w.Code, w.Header().Get("Content-Type"))
+	}
+
+	for _, path := range []string{"/images/missing.png", "/images/" + strings.TrimSuffix(name, ".png") + ".txt", "/images/..%2f..%2fgo.mod"} {
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want %d", path, w.Code, http.StatusNotFound)
+		}
+	}
+
+	if err := deleteThread(ctx, store, blobs, th.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{p.Image, p.Thumbnail} {
+		if _, err := blobs.Blob(ctx, key); !errors.Is(err, goreddit.ErrNotFound) {
+			t.Errorf("Blob(%q) after deleteThread = %v, want ErrNotFound", key, err)
+		}
+	}
+}
+
+func TestLimitBody(t *testing.T) {
+	defer func(size int64) { MaxImageSize = size }(MaxImageSize)
+	MaxImageSize = 1 << 20
+
+	var read int64
+	var readErr error
+	h := limitBody(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		read, readErr = io.Copy(io.Discard, r.Body)
+	}))
+
+	limit := MaxImageSize + 1<<20
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(make([]byte, limit+1))))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized request = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	// A body without a Content-Length is cut off where the limit is.
+	r := httptest.NewRequest(http.MethodPost, "/", io.MultiReader(bytes.NewReader(make([]byte, limit+1))))
+	r.ContentLength = -1
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if read != limit || readErr == nil {
+		t.Errorf("read %d bytes with error %v, want %d and an error", read, readErr, limit)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(make([]byte, limit)))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if read != limit || readErr != nil {
+		t.Errorf("read %d bytes with error %v, want %d", read, readErr, limit)
+	}
+}
@@ -0,0 +1,44 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/blrobin2/goreddit"
+)
+
+// KarmaThresholds is how much karma a user needs before they may take each
+// action. Zero leaves an action open to everyone who is logged in.
+type KarmaThresholds struct {
+	CreateThread  int
+	CreatePost    int
+	CreateComment int
+}
+
+// MinKarma gates actions behind karma thresholds. Admins are exempt.
+var MinKarma KarmaThresholds
+
+// hasKarma reports whether user may take an action that needs min karma.
+func hasKarma(user goreddit.User, min int) bool {
+	return min <= 0 || user.IsAdmin() || user.Karma() >= min
+}
+
+func notEnoughKarma(min int, action string) string {
+	return fmt.Sprintf("You need at least %d karma to %s.", min, action)
+}
+
+// requireKarma only lets through users with at least *min karma. It follows
+// requireLogin, which makes sure there is a user.
+func (p *errorPages) requireKarma(min *int, action string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+			if !hasKarma(user, *min) {
+				p.render(w, r, http.StatusForbidden, notEnoughKarma(*min, action))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
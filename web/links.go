@@ -0,0 +1,60 @@
+package web
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// MaxURLLength is the longest link a post may point at.
+const MaxURLLength = 2000
+
+// linkURL returns raw as it is stored for a link post, or false if it is not
+// an absolute http or https address. Links are normalized so that the same
+// page submitted twice is recognized as a duplicate.
+func linkURL(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || len(raw) > MaxURLLength {
+		return "", false
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.User != nil || u.Hostname() == "" {
+		return "", false
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	switch u.Scheme {
+	case "http", "https":
+	default:
+		return "", false
+	}
+
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	u.Host = host
+	u.Fragment, u.RawFragment = "", ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	return u.String(), true
+}
+
+// linkDomain returns the domain a link post is listed under: the host of its
+// link without a leading "www.".
+func linkDomain(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
@@ -0,0 +1,72 @@
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/blrobin2/goreddit"
+)
+
+var postSorts = []goreddit.PostSort{
+	goreddit.SortHot,
+	goreddit.SortNew,
+	goreddit.SortTop,
+	goreddit.SortControversial,
+}
+
+var listingWindows = []struct {
+	Name     string
+	Duration time.Duration
+}{
+	{"hour", time.Hour},
+	{"day", 24 * time.Hour},
+	{"week", 7 * 24 * time.Hour},
+	{"month", 30 * 24 * time.Hour},
+	{"year", 365 * 24 * time.Hour},
+	{"all", 0},
+}
+
+// ListingTabs is what the sort tabs above a post listing need to render.
+type ListingTabs struct {
+	Sort    goreddit.PostSort
+	Window  string
+	Sorts   []goreddit.PostSort
+	Windows []string
+}
+
+// ShowWindows reports whether the selected sort is limited to a time window.
+func (t ListingTabs) ShowWindows() bool {
+	return t.Sort == goreddit.SortTop || t.Sort == goreddit.SortControversial
+}
+
+// postListing reads the ?sort= and ?t= query parameters, falling back to hot
+// and to a day for top and controversial listings.
+func postListing(r *http.Request) (goreddit.PostListing, ListingTabs) {
+	tabs := ListingTabs{
+		Sort:   goreddit.SortHot,
+		Window: "day",
+		Sorts:  postSorts,
+	}
+	for _, w := range listingWindows {
+		tabs.Windows = append(tabs.Windows, w.Name)
+	}
+
+	q := r.URL.Query()
+	for _, s := range postSorts {
+		if string(s) == q.Get("sort") {
+			tabs.Sort = s
+		}
+	}
+
+	l := goreddit.PostListing{Sort: tabs.Sort}
+	for _, w := range listingWindows {
+		if w.Name == q.Get("t") {
+			tabs.Window = w.Name
+		}
+		if w.Name == tabs.Window {
+			l.Window = w.Duration
+		}
+	}
+
+	return l, tabs
+}
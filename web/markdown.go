@@ -0,0 +1,202 @@
+package web
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// markdown renders posts and comments from CommonMark with tables,
+// strikethrough and bare links. Raw HTML in the source is left out.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.Table, extension.Strikethrough, extension.Linkify),
+	goldmark.WithParserOptions(parser.WithASTTransformers(util.Prioritized(safeLinks{}, 100))),
+)
+
+// sanitizer is the last word on what rendered Markdown may contain, in case
+// something gets past safeLinks or the renderer.
+var sanitizer = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.RequireNoFollowOnLinks(false)
+	p.AllowAttrs("rel").Matching(regexp.MustCompile(`^nofollow ugc$`)).OnElements("a")
+	p.AllowAttrs("style").Matching(regexp.MustCompile(`^text-align:(left|center|right)$`)).OnElements("th", "td")
+
+	return p
+}()
+
+// renderMarkdown returns the HTML for Markdown source written by a user.
+func renderMarkdown(src string) string {
+	var b strings.Builder
+	if err := markdown.Convert([]byte(src), &b); err != nil {
+		return "<p>" + template.HTMLEscapeString(src) + "</p>"
+	}
+
+	return sanitizer.Sanitize(b.String())
+}
+
+// contentHTML returns the HTML stored for content, rendering src if there is
+// none, as for content RenderContent has not reached yet.
+func contentHTML(html, src string) template.HTML {
+	if html == "" && src != "" {
+		html = renderMarkdown(src)
+	}
+
+	return template.HTML(html)
+}
+
+// RenderContent saves the HTML for posts and comments that have none, such as
+// those written before content was rendered on save or whose HTML was cleared
+// so that it is rendered again. It is run when the server starts.
+func RenderContent(ctx context.Context, store goreddit.Store) error {
+	const batch = 100
+
+	for after := uuid.Nil; ; {
+		ps, err := store.UnrenderedPosts(ctx, after, batch)
+		if err != nil {
+			return err
+		}
+		for _, p := range ps {
+			if err := store.SetPostHTML(ctx, p.ID, renderMarkdown(p.Content)); err != nil {
+				return err
+			}
+			after = p.ID
+		}
+		if len(ps) < batch {
+			break
+		}
+	}
+
+	for after := uuid.Nil; ; {
+		cs, err := store.UnrenderedComments(ctx, after, batch)
+		if err != nil {
+			return err
+		}
+		for _, c := range cs {
+			if err := store.SetCommentHTML(ctx, c.ID, renderMarkdown(c.Content)); err != nil {
+				return err
+			}
+			after = c.ID
+		}
+		if len(cs) < batch {
+			break
+		}
+	}
+
+	return nil
+}
+
+// safeLinks only lets links point at web pages and email addresses, and
+// tells search engines not to follow the links users post. Images may only
+// be ones uploaded here; others are shown as links to them, so that viewing
+// a post does not load anything from another site.
+type safeLinks struct{}
+
+func (safeLinks) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	var unsafe []*ast.AutoLink
+	var remote []*ast.Image
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *ast.Link:
+			if !safeURL(n.Destination) {
+				n.Destination = nil
+			}
+			n.SetAttributeString("rel", []byte("nofollow ugc"))
+		case *ast.Image:
+			if !localImage(n.Destination) {
+				remote = append(remote, n)
+			}
+		case *ast.AutoLink:
+			if n.AutoLinkType == ast.AutoLinkURL && !safeURL(n.URL(reader.Source())) {
+				unsafe = append(unsafe, n)
+			} else {
+				n.SetAttributeString("rel", []byte("nofollow ugc"))
+			}
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	// Links that are not allowed are shown as the text they were written as.
+	for _, n := range unsafe {
+		n.Parent().ReplaceChild(n.Parent(), n, ast.NewString(n.Label(reader.Source())))
+	}
+
+	for _, n := range remote {
+		l := ast.NewLink()
+		if safeURL(n.Destination) {
+			l.Destination = n.Destination
+		}
+		l.Title = n.Title
+		l.SetAttributeString("rel", []byte("nofollow ugc"))
+		for c := n.FirstChild(); c != nil; {
+			next := c.NextSibling()
+			l.AppendChild(l, c)
+			c = next
+		}
+		n.Parent().ReplaceChild(n.Parent(), n, l)
+	}
+}
+
+// destination returns a link's destination as the renderer writes it, with
+// character references decoded. Checking it before they are would let
+// "javascript&#58;" through.
+func destination(dest []byte) string {
+	return string(util.ResolveEntityNames(util.ResolveNumericReferences(dest)))
+}
+
+// safeURL reports whether a link may point at dest: relative links and links
+// to web pages and email addresses may.
+func safeURL(dest []byte) bool {
+	u := destination(dest)
+	if html.IsDangerousURL([]byte(u)) {
+		return false
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(parsed.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	default:
+		return false
+	}
+}
+
+// localImage reports whether dest is an image uploaded to the site.
+func localImage(dest []byte) bool {
+	parsed, err := url.Parse(destination(dest))
+	if err != nil {
+		return false
+	}
+
+	return parsed.Scheme == "" && parsed.Host == "" && strings.HasPrefix(parsed.Path, "/images/")
+}
+
+// Preview renders Markdown for the preview on the post and comment forms.
+func (h *Handler) Preview() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(renderMarkdown(r.FormValue("content"))))
+	}
+}
@@ -0,0 +1,102 @@
+package web
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/blrobin2/goreddit/memory"
+	"github.com/google/uuid"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"emphasis", "*hi*", `<p><em>hi</em></p>`},
+		{"raw html", `hi <script>alert(1)</script><b onclick="x()">there</b>`, `<p>hi alert(1)there</p>`},
+		{"html block", "<div onmouseover=\"x()\">\nhi\n</div>", ``},
+		{"link", "[x](https://example.com/a)", `<p><a href="https://example.com/a" rel="nofollow ugc">x</a></p>`},
+		{"relative link", "[x](/posts/1)", `<p><a href="/posts/1" rel="nofollow ugc">x</a></p>`},
+		{"mailto link", "[x](mailto:a@example.com)", `<p><a href="mailto:a@example.com" rel="nofollow ugc">x</a></p>`},
+		{"javascript link", "[x](javascript:alert(1))", `<p><a rel="nofollow ugc">x</a></p>`},
+		{"mixed case javascript link", "[x](JaVaScRiPt:alert(1))", `<p><a rel="nofollow ugc">x</a></p>`},
+		{"numeric reference", "[x](javascript&#58;alert(1))", `<p><a rel="nofollow ugc">x</a></p>`},
+		{"hex reference", "[x](javascript&#x3a;alert(1))", `<p><a rel="nofollow ugc">x</a></p>`},
+		{"named reference", "[x](javascript&colon;alert(1))", `<p><a rel="nofollow ugc">x</a></p>`},
+		{"encoded letters", "[x](&#106;avascript:alert(1))", `<p><a rel="nofollow ugc">x</a></p>`},
+		{"vbscript link", "[x](vbscript:msgbox(1))", `<p><a rel="nofollow ugc">x</a></p>`},
+		{"data link", "[x](data:text/html;base64,PHNjcmlwdD4=)", `<p><a rel="nofollow ugc">x</a></p>`},
+		{"reference link", "[x][1]\n\n[1]: javascript&#58;alert(1)", `<p><a rel="nofollow ugc">x</a></p>`},
+		{"autolink", "<https://example.com>", `<p><a href="https://example.com" rel="nofollow ugc">https://example.com</a></p>`},
+		{"javascript autolink", "<javascript:alert(1)>", `<p>javascript:alert(1)</p>`},
+		{"email autolink", "<a@example.com>", `<p><a href="mailto:a@example.com" rel="nofollow ugc">a@example.com</a></p>`},
+		{"linkify", "see www.example.com", `<p>see <a href="http://www.example.com" rel="nofollow ugc">www.example.com</a></p>`},
+		{"uploaded image", "![cat](/images/1.png)", `<p><img src="/images/1.png" alt="cat"></p>`},
+		{"remote image", "![cat](http://tracker.example/x.png)", `<p><a href="http://tracker.example/x.png" rel="nofollow ugc">cat</a></p>`},
+		{"javascript image", "![cat](javascript&#x3a;alert(1))", `<p><a rel="nofollow ugc">cat</a></p>`},
+		{"protocol relative image", "![cat](//tracker.example/images/x.png)", `<p><a href="//tracker.example/images/x.png" rel="nofollow ugc">cat</a></p>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.TrimSpace(renderMarkdown(tt.src))
+			if got != tt.want {
+				t.Errorf("renderMarkdown(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdownTable(t *testing.T) {
+	got := renderMarkdown("| a | b |\n|:--|--:|\n| 1 | ~~2~~ |")
+	for _, want := range []string{`<table>`, `<th style="text-align:left">a</th>`, `<td style="text-align:right"><del>2</del></td>`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("renderMarkdown table = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestSanitizer(t *testing.T) {
+	got := sanitizer.Sanitize(`<a href="javascript:alert(1)" onclick="x()" rel="opener">x</a><img src="data:image/png;base64,AA=="><iframe src="https://example.com"></iframe>`)
+	if want := `x`; got != want {
+		t.Errorf("sanitizer.Sanitize = %q, want %q", got, want)
+	}
+}
+
+func TestRenderContent(t *testing.T) {
+	ctx := context.Background()
+	s := memory.NewStore()
+	th := goreddit.Thread{ID: uuid.New(), Title: "Thread"}
+	if err := s.CreateThread(ctx, &th); err != nil {
+		t.Fatal(err)
+	}
+	var posts []uuid.UUID
+	for i := 0; i < 150; i++ {
+		p := goreddit.Post{ID: uuid.New(), ThreadID: th.ID, Title: "Post", Content: "[x](javascript&#58;alert(1))"}
+		if err := s.CreatePost(ctx, &p); err != nil {
+			t.Fatal(err)
+		}
+		posts = append(posts, p.ID)
+	}
+	c := goreddit.Comment{ID: uuid.New(), PostID: posts[0], Content: "*hi*"}
+	if err := s.CreateComment(ctx, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RenderContent(ctx, s); err != nil {
+		t.Fatalf("RenderContent: %v", err)
+	}
+	for _, id := range posts {
+		p, _ := s.Post(ctx, id)
+		if want := `<p><a rel="nofollow ugc">x</a></p>`; strings.TrimSpace(p.ContentHTML) != want {
+			t.Fatalf("post HTML = %q, want %q", p.ContentHTML, want)
+		}
+	}
+	if got, _ := s.Comment(ctx, c.ID); strings.TrimSpace(got.ContentHTML) != `<p><em>hi</em></p>` {
+		t.Errorf("comment HTML = %q, want it rendered", got.ContentHTML)
+	}
+}
@@ -0,0 +1,101 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+// Reasons and comment excerpts are cut to these many characters in the
+// moderation log.
+const (
+	maxReasonLength  = 500
+	maxExcerptLength = 100
+)
+
+// modActionNames describes each moderator action on the moderation log page.
+var modActionNames = map[goreddit.ModAction]string{
+	goreddit.ModRemovePost:      "removed post",
+	goreddit.ModRemoveComment:   "removed comment",
+	goreddit.ModApprovePost:     "approved post",
+	goreddit.ModApproveComment:  "approved comment",
+	goreddit.ModLockPost:        "locked post",
+	goreddit.ModUnlockPost:      "unlocked post",
+	goreddit.ModPinPost:         "pinned post",
+	goreddit.ModUnpinPost:       "unpinned post",
+	goreddit.ModAddModerator:    "added moderator",
+	goreddit.ModRemoveModerator: "removed moderator",
+	goreddit.ModEditThread:      "edited thread",
+	goreddit.ModBanUser:         "banned user",
+	goreddit.ModUnbanUser:       "unbanned user",
+}
+
+// logModAction records that actor did something in a thread. e describes the
+// action and its target.
+func logModAction(ctx context.Context, store goreddit.Store, actor goreddit.User, e goreddit.ModLogEntry) error {
+	e.ID = uuid.New()
+	e.ActorID = actor.ID
+	return store.LogModAction(ctx, &e)
+}
+
+// updateThread saves t on behalf of actor. Edits by the thread's moderators
+// are logged; admins and creators who have stepped down edit unlogged.
+func updateThread(ctx context.Context, store goreddit.Store, actor goreddit.User, t *goreddit.Thread, reason string) error {
+	if err := store.UpdateThread(ctx, t); err != nil {
+		return err
+	}
+
+	mod, err := store.IsModerator(ctx, t.ID, actor.ID)
+	if err != nil || !mod {
+		return err
+	}
+	return logModAction(ctx, store, actor, goreddit.ModLogEntry{
+		ThreadID: t.ID,
+		Action:   goreddit.ModEditThread,
+		Target:   t.Title,
+		Reason:   reason,
+	})
+}
+
+// postEntry and commentEntry start a log entry for an action on a post or
+// a comment on post p.
+func postEntry(action goreddit.ModAction, p goreddit.Post, reason string) goreddit.ModLogEntry {
+	return goreddit.ModLogEntry{
+		ThreadID:     p.ThreadID,
+		Action:       action,
+		TargetUserID: p.UserID,
+		PostID:       p.ID,
+		Target:       p.Title,
+		Reason:       reason,
+	}
+}
+
+func commentEntry(action goreddit.ModAction, c goreddit.Comment, p goreddit.Post, reason string) goreddit.ModLogEntry {
+	return goreddit.ModLogEntry{
+		ThreadID:     p.ThreadID,
+		Action:       action,
+		TargetUserID: c.UserID,
+		PostID:       p.ID,
+		CommentID:    c.ID,
+		Target:       truncate(c.Content, maxExcerptLength),
+		Reason:       reason,
+	}
+}
+
+// modReason returns the reason a moderator gave for an action, if any.
+func modReason(r *http.Request) string {
+	return truncate(strings.TrimSpace(r.FormValue("reason")), maxReasonLength)
+}
+
+// truncate cuts s to at most n characters, marking where it was cut.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return string([]rune(s)[:n-1]) + "…"
+}
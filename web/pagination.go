@@ -0,0 +1,113 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 25
+	maxPageSize     = 100
+)
+
+// Pagination is what the previous and next links under a listing need to
+// render.
+type Pagination struct {
+	HasPrev bool
+	HasNext bool
+
+	query url.Values
+	first uuid.UUID
+	last  uuid.UUID
+}
+
+func (p Pagination) PrevURL() string {
+	q := p.cursorQuery()
+	q.Set("before", p.first.String())
+	return "?" + q.Encode()
+}
+
+func (p Pagination) NextURL() string {
+	q := p.cursorQuery()
+	q.Set("after", p.last.String())
+	return "?" + q.Encode()
+}
+
+func (p Pagination) cursorQuery() url.Values {
+	q := url.Values{}
+	for k, v := range p.query {
+		q[k] = v
+	}
+	q.Del("after")
+	q.Del("before")
+	return q
+}
+
+// pageRequest reads the ?after=, ?before= and ?limit= query parameters. The
+// returned page asks for one item more than will be shown so paginate can
+// tell whether there is more to come.
+func pageRequest(r *http.Request) (goreddit.Page, error) {
+	q := r.URL.Query()
+	p := goreddit.Page{Limit: defaultPageSize}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return goreddit.Page{}, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+		p.Limit = limit
+	}
+	p.Limit++
+
+	var err error
+	if v := q.Get("after"); v != "" {
+		if p.After, err = uuid.Parse(v); err != nil {
+			return goreddit.Page{}, err
+		}
+	}
+	if v := q.Get("before"); v != "" {
+		if p.Before, err = uuid.Parse(v); err != nil {
+			return goreddit.Page{}, err
+		}
+	}
+
+	return p, nil
+}
+
+// paginate takes the number of items a store returned for a page from
+// pageRequest, and a function returning the ID of the i-th one, and works out
+// which of them to show and which links to offer. Callers slice their items
+// to [start:end].
+func paginate(r *http.Request, p goreddit.Page, n int, id func(i int) uuid.UUID) (start, end int, nav Pagination) {
+	limit := p.Limit - 1
+	more := n > limit
+
+	start, end = 0, n
+	if more {
+		if p.Before != uuid.Nil {
+			start = n - limit
+		} else {
+			end = limit
+		}
+	}
+
+	nav.query = r.URL.Query()
+	switch {
+	case p.Before != uuid.Nil:
+		nav.HasPrev, nav.HasNext = more, true
+	case p.After != uuid.Nil:
+		nav.HasPrev, nav.HasNext = true, more
+	default:
+		nav.HasNext = more
+	}
+	if start < end {
+		nav.first, nav.last = id(start), id(end-1)
+	}
+
+	return start, end, nav
+}
@@ -1,9 +1,11 @@
 package web
 
 import (
+	"context"
+	"errors"
 	"html/template"
 	"net/http"
-	"sort"
+	"time"
 
 	"github.com/alexedwards/scs/v2"
 	"github.com/blrobin2/goreddit"
@@ -11,19 +13,27 @@ import (
 	"github.com/gorilla/csrf"
 )
 
+// MaxCommentDepth is how many levels of replies are rendered below a comment
+// before the rest of the discussion is hidden behind a "continue this thread"
+// link.
+var MaxCommentDepth = 6
+
 type PostHandler struct {
 	store    goreddit.Store
+	blobs    goreddit.BlobStore
 	sessions *scs.SessionManager
+	pages    *errorPages
 }
 
 func (h *PostHandler) New() http.HandlerFunc {
 	type data struct {
 		SessionData
-		CSRF   template.HTML
-		Thread goreddit.Thread
+		CSRF       template.HTML
+		Thread     goreddit.Thread
+		MaxImageMB int64
 	}
 
-	templ := template.Must(template.ParseFiles("templates/layout.html", "templates/post_create.html"))
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/post_create.html"))
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := getId(r, "id")
 		if err != nil {
@@ -31,9 +41,9 @@ func (h *PostHandler) New() http.HandlerFunc {
 			return
 		}
 
-		t, err := h.store.Thread(id)
+		t, err := h.store.Thread(r.Context(), id)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			h.pages.storeError(w, r, err)
 			return
 		}
 
@@ -41,6 +51,7 @@ func (h *PostHandler) New() http.HandlerFunc {
 			SessionData: GetSessionData(h.sessions, r.Context()),
 			CSRF:        csrf.TemplateField(r),
 			Thread:      t,
+			MaxImageMB:  MaxImageSize >> 20,
 		})
 	}
 }
@@ -50,9 +61,20 @@ func (h *PostHandler) Create() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		form := CreatePostForm{
 			Title:   r.FormValue("title"),
+			Kind:    r.FormValue("kind"),
+			URL:     r.FormValue("url"),
 			Content: r.FormValue("content"),
 		}
-		if !form.Validate() {
+		valid := form.Validate()
+		var img upload
+		if form.Kind == string(goreddit.PostImage) {
+			var msg string
+			if img, msg = readUpload(r, "image"); msg != "" {
+				form.Errors["Image"] = msg
+				valid = false
+			}
+		}
+		if !valid {
 			h.sessions.Put(r.Context(), "form", form)
 			http.Redirect(w, r, r.Referer(), http.StatusFound)
 			return
@@ -64,14 +86,52 @@ func (h *PostHandler) Create() http.HandlerFunc {
 			return
 		}
 
+		if form.Kind == string(goreddit.PostLink) {
+			dup, err := h.store.PostByURL(r.Context(), id, form.URL)
+			if err == nil {
+				form.Errors["URL"] = "That link has already been posted in this thread."
+				form.DuplicateID = dup.ID.String()
+				h.sessions.Put(r.Context(), "form", form)
+				http.Redirect(w, r, r.Referer(), http.StatusFound)
+				return
+			}
+			if !errors.Is(err, goreddit.ErrNotFound) {
+				h.pages.storeError(w, r, err)
+				return
+			}
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		msg, err := shutOut(r.Context(), h.store, user, id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if msg != "" {
+			h.pages.render(w, r, http.StatusForbidden, msg)
+			return
+		}
+
 		p := &goreddit.Post{
-			ID:       uuid.New(),
-			ThreadID: id,
-			Title:    form.Title,
-			Content:  form.Content,
+			ID:          uuid.New(),
+			ThreadID:    id,
+			UserID:      user.ID,
+			Title:       form.Title,
+			Content:     form.Content,
+			ContentHTML: renderMarkdown(form.Content),
+			Kind:        goreddit.PostKind(form.Kind),
+			URL:         form.URL,
+			Domain:      linkDomain(form.URL),
 		}
-		if err := h.store.CreatePost(p); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		if p.IsImage() {
+			if err := storeUpload(r.Context(), h.blobs, p, img); err != nil {
+				h.pages.storeError(w, r, err)
+				return
+			}
+		}
+		if err := h.store.CreatePost(r.Context(), p); err != nil {
+			deleteImages(r.Context(), h.blobs, *p)
+			h.pages.storeError(w, r, err)
 			return
 		}
 
@@ -84,11 +144,17 @@ func (h *PostHandler) Create() http.HandlerFunc {
 func (h *PostHandler) Show() http.HandlerFunc {
 	type data struct {
 		SessionData
-		CSRF     template.HTML
-		Post     goreddit.Post
-		Comments []goreddit.Comment
+		CSRF        template.HTML
+		CSRFToken   string
+		Post        goreddit.Post
+		Comments    []commentNode
+		Focused     bool
+		Page        Pagination
+		CanEdit     bool
+		CanModerate bool
+		ShutOut     string
 	}
-	templ := template.Must(template.ParseFiles("templates/layout.html", "templates/post.html"))
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/post.html"))
 	return func(w http.ResponseWriter, r *http.Request) {
 		postID, err := getId(r, "postID")
 		if err != nil {
@@ -96,31 +162,295 @@ func (h *PostHandler) Show() http.HandlerFunc {
 			return
 		}
 
-		p, err := h.store.Post(postID)
+		var rootID uuid.UUID
+		if v := r.URL.Query().Get("comment"); v != "" {
+			rootID, err = uuid.Parse(v)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		p, err := h.store.Post(r.Context(), postID)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			h.pages.storeError(w, r, err)
 			return
 		}
 
-		cs, err := h.store.CommentsByPost(postID)
+		// A focused comment may be anywhere in the tree, so it is looked up
+		// in the whole discussion rather than in a page of it.
+		page := goreddit.Page{}
+		if rootID == uuid.Nil {
+			if page, err = pageRequest(r); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		cs, err := h.store.CommentTree(r.Context(), postID, page)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			h.pages.storeError(w, r, err)
 			return
 		}
 
-		sort.SliceStable(cs, func(i, j int) bool {
-			return cs[i].Votes > cs[j].Votes
-		})
+		var nav Pagination
+		if rootID == uuid.Nil {
+			cs, nav = paginateTree(r, page, cs)
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		mod, err := canModerate(r.Context(), h.store, user, p.ThreadID)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		var shut string
+		if user.ID != uuid.Nil {
+			if shut, err = shutOut(r.Context(), h.store, user, p.ThreadID); err != nil {
+				h.pages.storeError(w, r, err)
+				return
+			}
+		}
+
+		nodes := commentTree(cs, rootID, MaxCommentDepth)
+		for i := range nodes {
+			nodes[i].CanEdit = !nodes[i].Deleted && canModify(user, nodes[i].UserID)
+			nodes[i].CanRemove = !nodes[i].Deleted && !nodes[i].CanEdit && mod
+		}
 
 		templ.Execute(w, data{
 			SessionData: GetSessionData(h.sessions, r.Context()),
 			CSRF:        csrf.TemplateField(r),
+			CSRFToken:   csrf.Token(r),
+			Post:        p,
+			Comments:    nodes,
+			Focused:     rootID != uuid.Nil,
+			Page:        nav,
+			CanEdit:     canModify(user, p.UserID),
+			CanModerate: mod,
+			ShutOut:     shut,
+		})
+	}
+}
+
+func (h *PostHandler) Edit() http.HandlerFunc {
+	type data struct {
+		SessionData
+		CSRF template.HTML
+		Post goreddit.Post
+	}
+
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/post_edit.html"))
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		if !canModify(user, p.UserID) {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		sd := GetSessionData(h.sessions, r.Context())
+		if _, ok := sd.Form.(CreatePostForm); !ok {
+			sd.Form = CreatePostForm{Title: p.Title, Kind: string(p.Kind), URL: p.URL, Content: p.Content}
+		}
+
+		templ.Execute(w, data{
+			SessionData: sd,
+			CSRF:        csrf.TemplateField(r),
 			Post:        p,
-			Comments:    cs,
 		})
 	}
 }
 
+func (h *PostHandler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		if !canModify(user, p.UserID) {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		// A post's kind and link stay as they were submitted.
+		form := CreatePostForm{
+			Title:   r.FormValue("title"),
+			Kind:    string(p.Kind),
+			URL:     p.URL,
+			Content: r.FormValue("content"),
+		}
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(w, r, r.Referer(), http.StatusFound)
+			return
+		}
+
+		now := time.Now()
+		p.Title = form.Title
+		p.Content = form.Content
+		p.ContentHTML = renderMarkdown(form.Content)
+		p.EditedAt = &now
+		if err := h.store.UpdatePost(r.Context(), &p); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", "Your post has been updated.")
+
+		http.Redirect(w, r, "/posts/"+p.ID.String(), http.StatusFound)
+	}
+}
+
+func (h *PostHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		ok, err := canRemove(r.Context(), h.store, user, p.UserID, p.ThreadID)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if !ok {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		if err := h.store.DeletePost(r.Context(), id); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		deleteImages(r.Context(), h.blobs, p)
+
+		if p.UserID == user.ID {
+			h.sessions.Put(r.Context(), "flash", "Your post has been deleted.")
+		} else {
+			if err := logModAction(r.Context(), h.store, user, postEntry(goreddit.ModRemovePost, p, modReason(r))); err != nil {
+				h.pages.storeError(w, r, err)
+				return
+			}
+			h.sessions.Put(r.Context(), "flash", "The post has been removed.")
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *PostHandler) Report() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		fileReport(w, r, h.store, h.sessions, id, uuid.Nil)
+	}
+}
+
+func (h *PostHandler) Approve() http.HandlerFunc {
+	return moderatePost(h, goreddit.ModApprovePost, "The post has been approved.", func(ctx context.Context, id uuid.UUID) error {
+		return h.store.ClearReports(ctx, id, uuid.Nil)
+	})
+}
+
+func (h *PostHandler) Lock() http.HandlerFunc {
+	return moderatePost(h, goreddit.ModLockPost, "The post has been locked.", func(ctx context.Context, id uuid.UUID) error {
+		return h.store.LockPost(ctx, id, true)
+	})
+}
+
+func (h *PostHandler) Unlock() http.HandlerFunc {
+	return moderatePost(h, goreddit.ModUnlockPost, "The post has been unlocked.", func(ctx context.Context, id uuid.UUID) error {
+		return h.store.LockPost(ctx, id, false)
+	})
+}
+
+func (h *PostHandler) Pin() http.HandlerFunc {
+	return moderatePost(h, goreddit.ModPinPost, "The post has been pinned.", func(ctx context.Context, id uuid.UUID) error {
+		return h.store.PinPost(ctx, id, true)
+	})
+}
+
+func (h *PostHandler) Unpin() http.HandlerFunc {
+	return moderatePost(h, goreddit.ModUnpinPost, "The post has been unpinned.", func(ctx context.Context, id uuid.UUID) error {
+		return h.store.PinPost(ctx, id, false)
+	})
+}
+
+// moderatePost lets the post's thread moderators apply change to it, and
+// records it in the moderation log as action.
+func moderatePost(h *PostHandler, action goreddit.ModAction, flash string, change func(ctx context.Context, id uuid.UUID) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		ok, err := canModerate(r.Context(), h.store, user, p.ThreadID)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if !ok {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		if err := change(r.Context(), id); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if err := logModAction(r.Context(), h.store, user, postEntry(action, p, modReason(r))); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", flash)
+
+		http.Redirect(w, r, backTo(r, "/posts/"+id.String()), http.StatusFound)
+	}
+}
+
 func (h *PostHandler) Upvote() http.HandlerFunc {
 	return voteOnPost(h, 1)
 }
@@ -129,23 +459,43 @@ func (h *PostHandler) Downvote() http.HandlerFunc {
 	return voteOnPost(h, -1)
 }
 
-func voteOnPost(h *PostHandler, newVoteAmount int) http.HandlerFunc {
+func voteOnPost(h *PostHandler, value int) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+
 		id, err := getId(r, "id")
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		p, err := h.store.Post(id)
+		p, err := h.store.Post(r.Context(), id)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			h.pages.storeError(w, r, err)
+			return
+		}
+		msg, err := shutOut(r.Context(), h.store, user, p.ThreadID)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if msg != "" {
+			http.Error(w, msg, http.StatusForbidden)
+			return
+		}
+
+		locked, err := lockedFor(r.Context(), h.store, user, p)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if locked {
+			http.Error(w, "This post is locked, so it takes no new votes.", http.StatusForbidden)
 			return
 		}
 
-		p.Votes += newVoteAmount
-		if err := h.store.UpdatePost(&p); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		if _, err := h.store.TogglePostVote(r.Context(), id, user.ID, value); err != nil {
+			h.pages.storeError(w, r, err)
 			return
 		}
 
@@ -0,0 +1,48 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+)
+
+// fileReport records the user's report against a post, or against one of its
+// comments if commentID is set. It answers the script on the post page, which
+// shows any error message to the user.
+func fileReport(w http.ResponseWriter, r *http.Request, store goreddit.Store, sessions *scs.SessionManager, postID, commentID uuid.UUID) {
+	form := ReportForm{
+		Reason: strings.TrimSpace(r.FormValue("reason")),
+	}
+	if !form.Validate() {
+		http.Error(w, form.Errors["Reason"], http.StatusBadRequest)
+		return
+	}
+
+	user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+	err := store.CreateReport(r.Context(), &goreddit.Report{
+		ID:        uuid.New(),
+		PostID:    postID,
+		CommentID: commentID,
+		UserID:    user.ID,
+		Reason:    form.Reason,
+	})
+	switch {
+	case errors.Is(err, goreddit.ErrConflict):
+		http.Error(w, "You have already reported this.", http.StatusConflict)
+		return
+	case errors.Is(err, goreddit.ErrNotFound):
+		http.Error(w, "This has been deleted.", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sessions.Put(r.Context(), "flash", "Thanks for your report. The moderators will take a look.")
+
+	w.WriteHeader(http.StatusNoContent)
+}
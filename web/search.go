@@ -0,0 +1,163 @@
+package web
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/blrobin2/goreddit"
+)
+
+const searchPageSize = 20
+
+type SearchHandler struct {
+	store    goreddit.Store
+	sessions *scs.SessionManager
+	pages    *errorPages
+}
+
+// SearchPagination links to the neighbouring pages of search results, which
+// are ranked rather than ordered by ID, so they are paged by number.
+type SearchPagination struct {
+	HasPrev bool
+	HasNext bool
+	PrevURL string
+	NextURL string
+}
+
+func (h *SearchHandler) Search() http.HandlerFunc {
+	type data struct {
+		SessionData
+		Query   string
+		Results []goreddit.SearchResult
+		Page    SearchPagination
+	}
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/search.html"))
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := strings.TrimSpace(r.URL.Query().Get("q"))
+
+		page := 1
+		if v := r.URL.Query().Get("page"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				http.Error(w, "page must be a positive number", http.StatusBadRequest)
+				return
+			}
+			page = n
+		}
+
+		var rs []goreddit.SearchResult
+		if q != "" {
+			var err error
+			rs, err = h.store.Search(r.Context(), parseSearch(q), searchPageSize+1, (page-1)*searchPageSize)
+			if err != nil {
+				h.pages.storeError(w, r, err)
+				return
+			}
+		}
+
+		var nav SearchPagination
+		if len(rs) > searchPageSize {
+			rs = rs[:searchPageSize]
+			nav.HasNext = true
+			nav.NextURL = searchURL(q, page+1)
+		}
+		if page > 1 {
+			nav.HasPrev = true
+			nav.PrevURL = searchURL(q, page-1)
+		}
+
+		templ.Execute(w, data{
+			SessionData: GetSessionData(h.sessions, r.Context()),
+			Query:       q,
+			Results:     rs,
+			Page:        nav,
+		})
+	}
+}
+
+func searchURL(q string, page int) string {
+	v := url.Values{"q": {q}}
+	if page > 1 {
+		v.Set("page", strconv.Itoa(page))
+	}
+	return "/search?" + v.Encode()
+}
+
+// parseSearch splits what was typed in the search box into a query. Quoted
+// text is matched as a phrase and every other word on its own, while
+// thread:name and author:name, which may also be quoted, filter the results.
+func parseSearch(s string) goreddit.SearchQuery {
+	var q goreddit.SearchQuery
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		var filter *string
+		switch {
+		case strings.HasPrefix(strings.ToLower(s), "thread:"):
+			filter, s = &q.Thread, s[len("thread:"):]
+		case strings.HasPrefix(strings.ToLower(s), "author:"):
+			filter, s = &q.Author, s[len("author:"):]
+		}
+
+		var term string
+		term, s = nextSearchTerm(s)
+		switch {
+		case filter != nil:
+			*filter = term
+		case term != "":
+			q.Phrases = append(q.Phrases, term)
+		}
+	}
+
+	return q
+}
+
+// nextSearchTerm returns the quoted text or word at the start of s, and what
+// follows it. An unterminated quote runs to the end of s.
+func nextSearchTerm(s string) (term, rest string) {
+	if strings.HasPrefix(s, `"`) {
+		s = s[1:]
+		if i := strings.IndexByte(s, '"'); i >= 0 {
+			return strings.TrimSpace(s[:i]), s[i+1:]
+		}
+		return strings.TrimSpace(s), ""
+	}
+
+	if i := strings.IndexFunc(s, unicode.IsSpace); i >= 0 {
+		return s[:i], s[i:]
+	}
+	return s, ""
+}
+
+// highlight escapes a search snippet and wraps the parts that matched the
+// query in <mark> tags.
+func highlight(snippet string) template.HTML {
+	var b strings.Builder
+	open := false
+	for snippet != "" {
+		i := strings.IndexAny(snippet, goreddit.MatchStart+goreddit.MatchEnd)
+		if i < 0 {
+			b.WriteString(template.HTMLEscapeString(snippet))
+			break
+		}
+		b.WriteString(template.HTMLEscapeString(snippet[:i]))
+
+		switch marker := snippet[i : i+1]; {
+		case marker == goreddit.MatchStart && !open:
+			b.WriteString("<mark>")
+			open = true
+		case marker == goreddit.MatchEnd && open:
+			b.WriteString("</mark>")
+			open = false
+		}
+		snippet = snippet[i+1:]
+	}
+	if open {
+		b.WriteString("</mark>")
+	}
+
+	return template.HTML(b.String())
+}
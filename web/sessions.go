@@ -2,10 +2,8 @@ package web
 
 import (
 	"context"
-	"database/sql"
 	"encoding/gob"
 
-	"github.com/alexedwards/scs/postgresstore"
 	"github.com/alexedwards/scs/v2"
 	"github.com/blrobin2/goreddit"
 	"github.com/google/uuid"
@@ -15,16 +13,14 @@ func init() {
 	gob.Register(uuid.UUID{})
 }
 
-func NewSessionManager(dataSourceName string) (*scs.SessionManager, error) {
-	db, err := sql.Open("postgres", dataSourceName)
-	if err != nil {
-		return nil, err
-	}
-
+// NewSessionManager keeps sessions in store, or in memory if store is nil.
+func NewSessionManager(store scs.Store) *scs.SessionManager {
 	sessions := scs.New()
-	sessions.Store = postgresstore.New(db)
+	if store != nil {
+		sessions.Store = store
+	}
 
-	return sessions, nil
+	return sessions
 }
 
 type SessionData struct {
@@ -0,0 +1,50 @@
+package web
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"time"
+)
+
+var funcs = template.FuncMap{
+	"timeAgo":    timeAgo,
+	"highlight":  highlight,
+	"profileURL": profileURL,
+	"markdown":   contentHTML,
+	"imageURL":   imageURL,
+}
+
+// profileURL returns the path of a user's profile page. Usernames may contain
+// any character, so they are escaped as a single path segment.
+func profileURL(username string) string {
+	return "/u/" + url.PathEscape(username)
+}
+
+// timeAgo describes how long ago t was in the coarsest sensible unit, e.g.
+// "3 hours ago".
+func timeAgo(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute") + " ago"
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour") + " ago"
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day") + " ago"
+	case d < 365*24*time.Hour:
+		return plural(int(d/(30*24*time.Hour)), "month") + " ago"
+	default:
+		return plural(int(d/(365*24*time.Hour)), "year") + " ago"
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+
+	return fmt.Sprintf("%d %ss", n, unit)
+}
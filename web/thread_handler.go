@@ -1,9 +1,10 @@
 package web
 
 import (
+	"errors"
 	"html/template"
 	"net/http"
-	"sort"
+	"strings"
 
 	"github.com/alexedwards/scs/v2"
 	"github.com/blrobin2/goreddit"
@@ -13,25 +14,37 @@ import (
 
 type ThreadHandler struct {
 	store    goreddit.Store
+	blobs    goreddit.BlobStore
 	sessions *scs.SessionManager
+	pages    *errorPages
 }
 
 func (h *ThreadHandler) List() http.HandlerFunc {
 	type data struct {
 		SessionData SessionData
 		Threads     []goreddit.Thread
+		Page        Pagination
 	}
-	templ := template.Must(template.ParseFiles("templates/layout.html", "templates/threads.html"))
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/threads.html"))
 	return func(w http.ResponseWriter, r *http.Request) {
-		ts, err := h.store.Threads()
+		page, err := pageRequest(r)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ts, err := h.store.Threads(r.Context(), page)
+		if err != nil {
+			h.pages.storeError(w, r, err)
 			return
 		}
 
+		start, end, nav := paginate(r, page, len(ts), func(i int) uuid.UUID { return ts[i].ID })
+
 		templ.Execute(w, data{
 			SessionData: GetSessionData(h.sessions, r.Context()),
-			Threads:     ts,
+			Threads:     ts[start:end],
+			Page:        nav,
 		})
 	}
 }
@@ -41,7 +54,7 @@ func (h *ThreadHandler) New() http.HandlerFunc {
 		SessionData
 		CSRF template.HTML
 	}
-	templ := template.Must(template.ParseFiles("templates/layout.html", "templates/thread_create.html"))
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/thread_create.html"))
 	return func(w http.ResponseWriter, r *http.Request) {
 		templ.Execute(w, data{
 			SessionData: GetSessionData(h.sessions, r.Context()),
@@ -53,37 +66,67 @@ func (h *ThreadHandler) New() http.HandlerFunc {
 func (h *ThreadHandler) Show() http.HandlerFunc {
 	type data struct {
 		SessionData
-		CSRFToken string
-		Thread    goreddit.Thread
-		Posts     []goreddit.Post
+		CSRFToken   string
+		Thread      goreddit.Thread
+		Posts       []goreddit.Post
+		Listing     ListingTabs
+		Page        Pagination
+		CanEdit     bool
+		CanDelete   bool
+		CanModerate bool
+		Moderators  []goreddit.ThreadModerator
 	}
-	templ := template.Must(template.ParseFiles("templates/layout.html", "templates/thread.html"))
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/thread.html"))
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := getId(r, "id")
 		if err != nil {
-			http.NotFound(w, r)
+			h.pages.notFound(w, r)
 			return
 		}
-		t, err := h.store.Thread(id)
+		t, err := h.store.Thread(r.Context(), id)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			h.pages.storeError(w, r, err)
 			return
 		}
-		ps, err := h.store.PostsByThead(id)
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+
+		l, tabs := postListing(r)
+		page, err := pageRequest(r)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		sort.SliceStable(ps, func(i, j int) bool {
-			return ps[i].Votes > ps[j].Votes
-		})
+		ps, err := h.store.PostsByThead(r.Context(), id, l, page)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		ms, err := h.store.Moderators(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		mod, err := canModerate(r.Context(), h.store, user, id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		start, end, nav := paginate(r, page, len(ps), func(i int) uuid.UUID { return ps[i].ID })
 
 		templ.Execute(w, data{
 			SessionData: GetSessionData(h.sessions, r.Context()),
 			CSRFToken:   csrf.Token(r),
 			Thread:      t,
-			Posts:       ps,
+			Posts:       ps[start:end],
+			Listing:     tabs,
+			Page:        nav,
+			CanEdit:     mod || canModify(user, t.UserID),
+			CanDelete:   canModify(user, t.UserID),
+			CanModerate: mod,
+			Moderators:  ms,
 		})
 	}
 }
@@ -100,12 +143,24 @@ func (h *ThreadHandler) Create() http.HandlerFunc {
 			return
 		}
 
-		if err := h.store.CreateThread(&goreddit.Thread{
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		msg, err := shutOut(r.Context(), h.store, user, uuid.Nil)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if msg != "" {
+			h.pages.render(w, r, http.StatusForbidden, msg)
+			return
+		}
+
+		if err := h.store.CreateThread(r.Context(), &goreddit.Thread{
 			ID:          uuid.New(),
+			UserID:      user.ID,
 			Title:       form.Title,
 			Description: form.Description,
 		}); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			h.pages.storeError(w, r, err)
 			return
 		}
 
@@ -115,6 +170,106 @@ func (h *ThreadHandler) Create() http.HandlerFunc {
 	}
 }
 
+func (h *ThreadHandler) Edit() http.HandlerFunc {
+	type data struct {
+		SessionData
+		CSRF        template.HTML
+		Thread      goreddit.Thread
+		IsModerator bool
+	}
+
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/thread_edit.html"))
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		ok, err := canEditThread(r.Context(), h.store, user, t)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if !ok {
+			h.pages.forbidden(w, r)
+			return
+		}
+		mod, err := h.store.IsModerator(r.Context(), t.ID, user.ID)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		sd := GetSessionData(h.sessions, r.Context())
+		if _, ok := sd.Form.(CreateThreadForm); !ok {
+			sd.Form = CreateThreadForm{Title: t.Title, Description: t.Description}
+		}
+
+		templ.Execute(w, data{
+			SessionData: sd,
+			CSRF:        csrf.TemplateField(r),
+			Thread:      t,
+			IsModerator: mod,
+		})
+	}
+}
+
+func (h *ThreadHandler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		ok, err := canEditThread(r.Context(), h.store, user, t)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if !ok {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		form := CreateThreadForm{
+			Title:       r.FormValue("title"),
+			Description: r.FormValue("description"),
+		}
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(w, r, r.Referer(), http.StatusFound)
+			return
+		}
+
+		t.Title = form.Title
+		t.Description = form.Description
+		if err := updateThread(r.Context(), h.store, user, &t, modReason(r)); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", "The thread has been updated.")
+
+		http.Redirect(w, r, "/threads/"+t.ID.String(), http.StatusFound)
+	}
+}
+
 func (h *ThreadHandler) Delete() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := getId(r, "id")
@@ -123,8 +278,20 @@ func (h *ThreadHandler) Delete() http.HandlerFunc {
 			return
 		}
 
-		if err := h.store.DeleteThread(id); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		if !canModify(user, t.UserID) {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		if err := deleteThread(r.Context(), h.store, h.blobs, id); err != nil {
+			h.pages.storeError(w, r, err)
 			return
 		}
 
@@ -133,3 +300,498 @@ func (h *ThreadHandler) Delete() http.HandlerFunc {
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+func (h *ThreadHandler) Moderators() http.HandlerFunc {
+	type moderator struct {
+		goreddit.ThreadModerator
+		CanRemove bool
+	}
+	type data struct {
+		SessionData
+		CSRF        template.HTML
+		CSRFToken   string
+		Thread      goreddit.Thread
+		Moderators  []moderator
+		CanModerate bool
+	}
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/thread_moderators.html"))
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			h.pages.notFound(w, r)
+			return
+		}
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		ms, err := h.store.Moderators(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		mod, err := canModerate(r.Context(), h.store, user, id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		mods := make([]moderator, len(ms))
+		for i, m := range ms {
+			mods[i] = moderator{ThreadModerator: m, CanRemove: canRemoveModerator(user, ms, m)}
+		}
+
+		templ.Execute(w, data{
+			SessionData: GetSessionData(h.sessions, r.Context()),
+			CSRF:        csrf.TemplateField(r),
+			CSRFToken:   csrf.Token(r),
+			Thread:      t,
+			Moderators:  mods,
+			CanModerate: mod,
+		})
+	}
+}
+
+func (h *ThreadHandler) AddModerator() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, err := h.store.Thread(r.Context(), id); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		ok, err := canModerate(r.Context(), h.store, user, id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if !ok {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		form := AddModeratorForm{
+			Username: r.FormValue("username"),
+		}
+		u, err := h.store.UserByUsername(r.Context(), form.Username)
+		if errors.Is(err, goreddit.ErrNotFound) {
+			form.UnknownUser = true
+		} else if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		if !form.UnknownUser {
+			err = h.store.AddModerator(r.Context(), &goreddit.ThreadModerator{ThreadID: id, UserID: u.ID})
+			if errors.Is(err, goreddit.ErrConflict) {
+				form.AlreadyModerator = true
+			} else if err != nil {
+				h.pages.storeError(w, r, err)
+				return
+			}
+		}
+
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+		} else {
+			if err := logModAction(r.Context(), h.store, user, goreddit.ModLogEntry{ThreadID: id, Action: goreddit.ModAddModerator, TargetUserID: u.ID, Reason: modReason(r)}); err != nil {
+				h.pages.storeError(w, r, err)
+				return
+			}
+			h.sessions.Put(r.Context(), "flash", u.Username+" is now a moderator.")
+		}
+
+		http.Redirect(w, r, "/threads/"+id.String()+"/moderators", http.StatusFound)
+	}
+}
+
+func (h *ThreadHandler) RemoveModerator() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		userID, err := getId(r, "userID")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ms, err := h.store.Moderators(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		var target *goreddit.ThreadModerator
+		for i := range ms {
+			if ms[i].UserID == userID {
+				target = &ms[i]
+			}
+		}
+		if target == nil {
+			h.pages.notFound(w, r)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		if !canRemoveModerator(user, ms, *target) {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		if err := h.store.RemoveModerator(r.Context(), id, userID); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if err := logModAction(r.Context(), h.store, user, goreddit.ModLogEntry{ThreadID: id, Action: goreddit.ModRemoveModerator, TargetUserID: userID, Reason: modReason(r)}); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		if userID == user.ID {
+			h.sessions.Put(r.Context(), "flash", "You are no longer a moderator.")
+		} else {
+			h.sessions.Put(r.Context(), "flash", target.Username+" is no longer a moderator.")
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// canRemoveModerator reports whether user may remove m from a thread
+// moderated by ms. Moderators may step down and remove those appointed after
+// them; admins may remove anyone.
+func canRemoveModerator(user goreddit.User, ms []goreddit.ThreadModerator, m goreddit.ThreadModerator) bool {
+	if user.IsAdmin() || (user.ID != uuid.Nil && user.ID == m.UserID) {
+		return true
+	}
+
+	for _, mod := range ms {
+		if mod.UserID == user.ID {
+			return mod.CreatedAt.Before(m.CreatedAt)
+		}
+	}
+
+	return false
+}
+
+func (h *ThreadHandler) ModLog() http.HandlerFunc {
+	type entry struct {
+		goreddit.ModLogEntry
+		ActionName string
+		PostURL    string
+		Quote      bool
+	}
+	type action struct {
+		Value    goreddit.ModAction
+		Name     string
+		Selected bool
+	}
+	type data struct {
+		SessionData
+		Thread     goreddit.Thread
+		Entries    []entry
+		Actions    []action
+		Moderator  string
+		Moderators []goreddit.ThreadModerator
+		Page       Pagination
+	}
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/thread_modlog.html"))
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			h.pages.notFound(w, r)
+			return
+		}
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		page, err := pageRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		f := goreddit.ModLogFilter{Action: goreddit.ModAction(r.URL.Query().Get("action"))}
+		if _, ok := modActionNames[f.Action]; f.Action != "" && !ok {
+			http.Error(w, "unknown action "+string(f.Action), http.StatusBadRequest)
+			return
+		}
+
+		// An unknown moderator has done nothing, so their log is empty.
+		es := []goreddit.ModLogEntry{}
+		found := true
+		moderator := r.URL.Query().Get("moderator")
+		if moderator != "" {
+			u, err := h.store.UserByUsername(r.Context(), moderator)
+			if errors.Is(err, goreddit.ErrNotFound) {
+				found = false
+			} else if err != nil {
+				h.pages.storeError(w, r, err)
+				return
+			}
+			f.ActorID = u.ID
+		}
+		if found {
+			if es, err = h.store.ModLog(r.Context(), id, f, page); err != nil {
+				h.pages.storeError(w, r, err)
+				return
+			}
+		}
+
+		ms, err := h.store.Moderators(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		start, end, nav := paginate(r, page, len(es), func(i int) uuid.UUID { return es[i].ID })
+
+		entries := []entry{}
+		for _, e := range es[start:end] {
+			en := entry{ModLogEntry: e, ActionName: modActionNames[e.Action], Quote: e.CommentID != uuid.Nil}
+			if e.Action == goreddit.ModRemoveModerator && e.ActorID != uuid.Nil && e.ActorID == e.TargetUserID {
+				en.ActionName, en.TargetName = "stepped down as moderator", ""
+			}
+			// Removed posts are gone, so there is nothing to link to.
+			if e.PostID != uuid.Nil && e.Action != goreddit.ModRemovePost {
+				en.PostURL = "/posts/" + e.PostID.String()
+			}
+			entries = append(entries, en)
+		}
+		actions := []action{}
+		for _, a := range goreddit.ModActions {
+			actions = append(actions, action{Value: a, Name: modActionNames[a], Selected: a == f.Action})
+		}
+
+		templ.Execute(w, data{
+			SessionData: GetSessionData(h.sessions, r.Context()),
+			Thread:      t,
+			Entries:     entries,
+			Actions:     actions,
+			Moderator:   moderator,
+			Moderators:  ms,
+			Page:        nav,
+		})
+	}
+}
+
+func (h *ThreadHandler) Queue() http.HandlerFunc {
+	type data struct {
+		SessionData
+		CSRF      template.HTML
+		CSRFToken string
+		Thread    goreddit.Thread
+		Items     []goreddit.ReportedItem
+	}
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/thread_queue.html"))
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			h.pages.notFound(w, r)
+			return
+		}
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		ok, err := canModerate(r.Context(), h.store, user, id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if !ok {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		items, err := h.store.ReportQueue(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		templ.Execute(w, data{
+			SessionData: GetSessionData(h.sessions, r.Context()),
+			CSRF:        csrf.TemplateField(r),
+			CSRFToken:   csrf.Token(r),
+			Thread:      t,
+			Items:       items,
+		})
+	}
+}
+
+func (h *ThreadHandler) Bans() http.HandlerFunc {
+	type data struct {
+		SessionData
+		CSRF      template.HTML
+		CSRFToken string
+		Thread    goreddit.Thread
+		Bans      []goreddit.ThreadBan
+	}
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/thread_bans.html"))
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			h.pages.notFound(w, r)
+			return
+		}
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		ok, err := canModerate(r.Context(), h.store, user, id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if !ok {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		bs, err := h.store.ThreadBans(r.Context(), id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		// The ban links next to a user's content fill in their name.
+		sd := GetSessionData(h.sessions, r.Context())
+		if _, ok := sd.Form.(BanUserForm); !ok {
+			sd.Form = BanUserForm{Username: r.URL.Query().Get("username"), Duration: "7d"}
+		}
+
+		templ.Execute(w, data{
+			SessionData: sd,
+			CSRF:        csrf.TemplateField(r),
+			CSRFToken:   csrf.Token(r),
+			Thread:      t,
+			Bans:        bs,
+		})
+	}
+}
+
+func (h *ThreadHandler) Ban() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, err := h.store.Thread(r.Context(), id); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		ok, err := canModerate(r.Context(), h.store, user, id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if !ok {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		form := BanUserForm{
+			Username: r.FormValue("username"),
+			Reason:   strings.TrimSpace(r.FormValue("reason")),
+			Duration: r.FormValue("duration"),
+		}
+		u, err := h.store.UserByUsername(r.Context(), form.Username)
+		if errors.Is(err, goreddit.ErrNotFound) {
+			form.UnknownUser = true
+		} else if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		} else if form.Moderator, err = canModerate(r.Context(), h.store, u, id); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(w, r, "/threads/"+id.String()+"/bans", http.StatusFound)
+			return
+		}
+
+		if err := h.store.BanUser(r.Context(), &goreddit.ThreadBan{
+			ThreadID:  id,
+			UserID:    u.ID,
+			BannedBy:  user.ID,
+			Reason:    form.Reason,
+			ExpiresAt: banExpiry(form.Duration),
+		}); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if err := logModAction(r.Context(), h.store, user, goreddit.ModLogEntry{ThreadID: id, Action: goreddit.ModBanUser, TargetUserID: u.ID, Reason: form.Reason}); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", u.Username+" has been banned from this thread.")
+
+		http.Redirect(w, r, "/threads/"+id.String()+"/bans", http.StatusFound)
+	}
+}
+
+func (h *ThreadHandler) Unban() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		userID, err := getId(r, "userID")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		ok, err := canModerate(r.Context(), h.store, user, id)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if !ok {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		if err := h.store.UnbanUser(r.Context(), id, userID); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if err := logModAction(r.Context(), h.store, user, goreddit.ModLogEntry{ThreadID: id, Action: goreddit.ModUnbanUser, TargetUserID: userID, Reason: modReason(r)}); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", "The ban has been lifted.")
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,149 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/blrobin2/goreddit"
+	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
+)
+
+// apiTokenPrefix marks goreddit tokens so they are easy to spot in scripts
+// and secret scanners.
+const apiTokenPrefix = "grt_"
+
+var errAPITokenNotFound = errors.New("API token not found.")
+
+type TokenHandler struct {
+	store    goreddit.Store
+	sessions *scs.SessionManager
+}
+
+func (h *TokenHandler) List() http.HandlerFunc {
+	type data struct {
+		SessionData
+		CSRF      template.HTML
+		CSRFToken string
+		Tokens    []goreddit.APIToken
+		NewToken  string
+	}
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/tokens.html"))
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+
+		ts, err := h.store.APITokens(r.Context(), user.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		templ.Execute(w, data{
+			SessionData: GetSessionData(h.sessions, r.Context()),
+			CSRF:        csrf.TemplateField(r),
+			CSRFToken:   csrf.Token(r),
+			Tokens:      ts,
+			NewToken:    h.sessions.PopString(r.Context(), "api_token"),
+		})
+	}
+}
+
+func (h *TokenHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+
+		form := CreateTokenForm{
+			Name: r.FormValue("name"),
+		}
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(w, r, r.Referer(), http.StatusFound)
+			return
+		}
+
+		plain, t, err := generateAPIToken(user.ID, form.Name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := h.store.CreateAPIToken(r.Context(), t); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "api_token", plain)
+		h.sessions.Put(r.Context(), "flash", "Your API token has been created. Copy it now, it will not be shown again.")
+		http.Redirect(w, r, "/tokens", http.StatusFound)
+	}
+}
+
+func (h *TokenHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+
+		id, err := getId(r, "id")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if status, err := revokeAPIToken(r.Context(), h.store, user, id); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", "Your API token has been revoked.")
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// generateAPIToken generates a token for a user, returning the token to hand out
+// and the record to store.
+func generateAPIToken(userID uuid.UUID, name string) (string, *goreddit.APIToken, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+
+	plain := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return plain, &goreddit.APIToken{
+		ID:     uuid.New(),
+		UserID: userID,
+		Name:   name,
+		Hash:   hashAPIToken(plain),
+	}, nil
+}
+
+// hashAPIToken hashes a token for storage. Tokens are random and long enough
+// that a fast hash is sufficient; unlike passwords they cannot be guessed.
+func hashAPIToken(plain string) []byte {
+	sum := sha256.Sum256([]byte(plain))
+	return sum[:]
+}
+
+// revokeAPIToken deletes one of the user's tokens, returning the status code
+// to respond with if it cannot.
+func revokeAPIToken(ctx context.Context, store goreddit.Store, user goreddit.User, id uuid.UUID) (int, error) {
+	ts, err := store.APITokens(ctx, user.ID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	for _, t := range ts {
+		if t.ID == id {
+			if err := store.DeleteAPIToken(ctx, id); err != nil {
+				return http.StatusInternalServerError, err
+			}
+			return http.StatusNoContent, nil
+		}
+	}
+
+	return http.StatusNotFound, errAPITokenNotFound
+}
@@ -1,11 +1,15 @@
 package web
 
 import (
+	"errors"
 	"html/template"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/alexedwards/scs/v2"
 	"github.com/blrobin2/goreddit"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/gorilla/csrf"
 	"golang.org/x/crypto/bcrypt"
@@ -14,6 +18,7 @@ import (
 type UserHandler struct {
 	store    goreddit.Store
 	sessions *scs.SessionManager
+	pages    *errorPages
 }
 
 type key int
@@ -22,12 +27,204 @@ const (
 	KeyUserID key = iota
 )
 
+// Profile is what the header of a user's profile page needs to render.
+type Profile struct {
+	User       goreddit.User
+	Tab        string
+	Suspension *goreddit.Suspension
+	CSRF       template.HTML
+}
+
+// profile looks up what the header of u's profile page shows on tab.
+func (h *UserHandler) profile(r *http.Request, u goreddit.User, tab string) (Profile, error) {
+	p := Profile{User: u, Tab: tab, CSRF: csrf.TemplateField(r)}
+
+	s, err := h.store.Suspension(r.Context(), u.ID)
+	if err == nil {
+		p.Suspension = &s
+	} else if !errors.Is(err, goreddit.ErrNotFound) {
+		return Profile{}, err
+	}
+
+	return p, nil
+}
+
+func (h *UserHandler) Posts() http.HandlerFunc {
+	type data struct {
+		SessionData
+		Profile  Profile
+		Posts    []goreddit.Post
+		Comments []goreddit.Comment
+		Page     Pagination
+	}
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/user.html"))
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, err := h.store.UserByUsername(r.Context(), usernameParam(r))
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		page, err := pageRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ps, err := h.store.PostsByUser(r.Context(), u.ID, page)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		start, end, nav := paginate(r, page, len(ps), func(i int) uuid.UUID { return ps[i].ID })
+
+		profile, err := h.profile(r, u, "posts")
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		templ.Execute(w, data{
+			SessionData: GetSessionData(h.sessions, r.Context()),
+			Profile:     profile,
+			Posts:       ps[start:end],
+			Page:        nav,
+		})
+	}
+}
+
+func (h *UserHandler) Comments() http.HandlerFunc {
+	type data struct {
+		SessionData
+		Profile  Profile
+		Posts    []goreddit.Post
+		Comments []goreddit.Comment
+		Page     Pagination
+	}
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/user.html"))
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, err := h.store.UserByUsername(r.Context(), usernameParam(r))
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		page, err := pageRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		cs, err := h.store.CommentsByUser(r.Context(), u.ID, page)
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		start, end, nav := paginate(r, page, len(cs), func(i int) uuid.UUID { return cs[i].ID })
+
+		profile, err := h.profile(r, u, "comments")
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		templ.Execute(w, data{
+			SessionData: GetSessionData(h.sessions, r.Context()),
+			Profile:     profile,
+			Comments:    cs[start:end],
+			Page:        nav,
+		})
+	}
+}
+
+// usernameParam returns the username in a profile URL. The router matches
+// the raw path when it contains escaped slashes, leaving those unescaped.
+func usernameParam(r *http.Request) string {
+	username := chi.URLParam(r, "username")
+	if r.URL.RawPath == "" {
+		return username
+	}
+	if u, err := url.PathUnescape(username); err == nil {
+		return u
+	}
+	return username
+}
+
+func (h *UserHandler) Suspend() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		if !user.IsAdmin() {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		u, err := h.store.UserByUsername(r.Context(), usernameParam(r))
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+		if u.IsAdmin() {
+			h.pages.render(w, r, http.StatusForbidden, "Admins cannot be suspended.")
+			return
+		}
+
+		form := SuspendUserForm{
+			Reason:   strings.TrimSpace(r.FormValue("reason")),
+			Duration: r.FormValue("duration"),
+		}
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(w, r, profileURL(u.Username), http.StatusFound)
+			return
+		}
+
+		if err := h.store.SuspendUser(r.Context(), &goreddit.Suspension{
+			UserID:      u.ID,
+			SuspendedBy: user.ID,
+			Reason:      form.Reason,
+			ExpiresAt:   banExpiry(form.Duration),
+		}); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", u.Username+" has been suspended.")
+		http.Redirect(w, r, profileURL(u.Username), http.StatusFound)
+	}
+}
+
+func (h *UserHandler) Unsuspend() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := r.Context().Value(KeyUserID).(goreddit.User)
+		if !user.IsAdmin() {
+			h.pages.forbidden(w, r)
+			return
+		}
+
+		u, err := h.store.UserByUsername(r.Context(), usernameParam(r))
+		if err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		if err := h.store.UnsuspendUser(r.Context(), u.ID); err != nil {
+			h.pages.storeError(w, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", u.Username+" is no longer suspended.")
+		http.Redirect(w, r, profileURL(u.Username), http.StatusFound)
+	}
+}
+
 func (h *UserHandler) New() http.HandlerFunc {
 	type data struct {
 		SessionData
 		CSRF template.HTML
 	}
-	templ := template.Must(template.ParseFiles("templates/layout.html", "templates/user_register.html"))
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/user_register.html"))
 	return func(w http.ResponseWriter, r *http.Request) {
 		templ.Execute(w, data{
 			SessionData: GetSessionData(h.sessions, r.Context()),
@@ -44,7 +241,7 @@ func (h *UserHandler) Register() http.HandlerFunc {
 			PasswordConfirm: r.FormValue("password-confirm"),
 			UsernameTaken:   false,
 		}
-		if _, err := h.store.UserByUsername(form.Username); err == nil {
+		if _, err := h.store.UserByUsername(r.Context(), form.Username); err == nil {
 			form.UsernameTaken = true
 		}
 		if !form.Validate() {
@@ -59,12 +256,12 @@ func (h *UserHandler) Register() http.HandlerFunc {
 			return
 		}
 
-		if err := h.store.CreateUser(&goreddit.User{
+		if err := h.store.CreateUser(r.Context(), &goreddit.User{
 			ID:       uuid.New(),
 			Username: form.Username,
 			Password: string(password),
 		}); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			h.pages.storeError(w, r, err)
 			return
 		}
 
@@ -78,7 +275,7 @@ func (h *UserHandler) LoginForm() http.HandlerFunc {
 		SessionData
 		CSRF template.HTML
 	}
-	templ := template.Must(template.ParseFiles("templates/layout.html", "templates/user_login.html"))
+	templ := template.Must(template.New("layout.html").Funcs(funcs).ParseFiles("templates/layout.html", "templates/user_login.html"))
 	return func(w http.ResponseWriter, r *http.Request) {
 		templ.Execute(w, data{
 			SessionData: GetSessionData(h.sessions, r.Context()),
@@ -94,13 +291,21 @@ func (h *UserHandler) Login() http.HandlerFunc {
 			Password:                  r.FormValue("password"),
 			InvalidUsernameOrPassword: false,
 		}
-		user, err := h.store.UserByUsername(form.Username)
+		user, err := h.store.UserByUsername(r.Context(), form.Username)
 		if err != nil {
 			form.InvalidUsernameOrPassword = true
 		} else {
 			compareErr := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(form.Password))
 			form.InvalidUsernameOrPassword = compareErr != nil
 		}
+		if !form.InvalidUsernameOrPassword {
+			msg, err := shutOut(r.Context(), h.store, user, uuid.Nil)
+			if err != nil {
+				h.pages.storeError(w, r, err)
+				return
+			}
+			form.Suspended = msg
+		}
 		if !form.Validate() {
 			h.sessions.Put(r.Context(), "form", form)
 			http.Redirect(w, r, r.Referer(), http.StatusFound)